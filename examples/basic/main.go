@@ -116,8 +116,8 @@ func main() {
 		log.Fatalf("Failed to check for updates (legacy): %v", err)
 	}
 
-	if updateInfo.HasUpdate && updateInfo.LatestVersion != nil {
-		fmt.Printf("旧版API检测到更新: %s -> %s\n", currentVersion, updateInfo.LatestVersion.Version)
+	if updateInfo.HasUpdate && updateInfo.LatestVersion != "" {
+		fmt.Printf("旧版API检测到更新: %s -> %s\n", currentVersion, updateInfo.LatestVersion)
 	}
 	
 	// 显示更新历史
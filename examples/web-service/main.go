@@ -24,7 +24,7 @@ func main() {
 	go startWebServer()
 
 	// 启动更新检查器
-	go startUpdateChecker()
+	startUpdateChecker()
 
 	// 等待信号
 	waitForSignal()
@@ -71,6 +71,7 @@ func startUpdateChecker() {
 		PreserveFiles: []string{"config.yaml", "config.yml", "*.conf", "data.db", "logs/*"},
 		BackupCount:   5,
 		UpdateMode:    client.UpdateModeAuto, // 🆕 设置更新模式
+		CurrentVersion: VERSION,
 	}
 
 	updater, err := client.NewClient(config)
@@ -79,15 +80,35 @@ func startUpdateChecker() {
 		return
 	}
 
-	// 定时检查更新（每30分钟）
-	ticker := time.NewTicker(30 * time.Minute)
-	defer ticker.Stop()
+	// 🆕 重启前优雅关闭服务器
+	updater.RegisterShutdownHook(shutdownServer)
+
+	// 🆕 使用内置调度器定时检查更新（每30分钟，附加最多5分钟随机抖动）
+	scheduler, err := updater.NewScheduler(client.SchedulerOptions{
+		Interval:     30 * time.Minute,
+		Jitter:       5 * time.Minute,
+		InitialDelay: time.Minute,
+		OnUpdateAvailable: func(version *client.VersionInfo) {
+			log.Printf("发现可用更新版本: %s", version.Version)
+		},
+		OnUpdated: func(version *client.VersionInfo) {
+			log.Printf("更新成功，版本: %s，正在重启...", version.Version)
+			// 🆕 重新执行新安装的可执行文件，成功时不会返回
+			if err := updater.Restart(context.Background()); err != nil {
+				log.Printf("重启失败: %v", err)
+			}
+		},
+		OnError: func(err error) {
+			log.Printf("自动更新失败: %v", err)
+		},
+	})
+	if err != nil {
+		log.Printf("Failed to create update scheduler: %v", err)
+		return
+	}
 
-	for {
-		select {
-		case <-ticker.C:
-			checkAndUpdate(updater)
-		}
+	if err := scheduler.Start(context.Background()); err != nil {
+		log.Printf("Failed to start update scheduler: %v", err)
 	}
 }
 
@@ -132,16 +153,7 @@ func checkAndUpdate(updater *client.Client) {
 
 	log.Println("开始执行更新...")
 
-	// 优雅关闭服务器
-	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer shutdownCancel()
-
-	if err := server.Shutdown(shutdownCtx); err != nil {
-		log.Printf("服务器关闭失败: %v", err)
-		return
-	}
-
-	// 🆕 使用新的更新方法
+	// 🆕 使用新的更新方法，更新期间服务继续运行
 	err = updater.UpdateToVersion(ctx, recommendedVersion.Version, func(progress *client.DownloadProgress) {
 		if progress.Total > 0 {
 			log.Printf("下载进度: %.1f%%", progress.Percentage)
@@ -150,18 +162,27 @@ func checkAndUpdate(updater *client.Client) {
 
 	if err != nil {
 		log.Printf("更新失败: %v", err)
-		// 重新启动服务器
-		go startWebServer()
 		return
 	}
 
-	log.Printf("更新成功，版本: %s", recommendedVersion.Version)
-	
-	// 更新版本号
-	VERSION = recommendedVersion.Version
+	log.Printf("更新成功，版本: %s，正在重启...", recommendedVersion.Version)
 
-	// 重新启动服务器
-	go startWebServer()
+	// 🆕 执行创建客户端时注册的关闭钩子，然后重新执行新版本的可执行文件
+	if err := updater.Restart(context.Background()); err != nil {
+		log.Printf("重启失败: %v", err)
+	}
+}
+
+// shutdownServer 优雅关闭服务器
+func shutdownServer(ctx context.Context) error {
+	if server == nil {
+		return nil
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	return server.Shutdown(shutdownCtx)
 }
 
 func handleManualUpdate(w http.ResponseWriter, r *http.Request) {
@@ -188,6 +209,9 @@ func handleManualUpdate(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// 重启前优雅关闭服务器
+	updater.RegisterShutdownHook(shutdownServer)
+
 	// 在后台执行更新
 	go func() {
 		checkAndUpdate(updater)
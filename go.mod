@@ -1,3 +1,8 @@
 module github.com/CooperJiang/versiontrack-go-sdk
 
 go 1.21
+
+require (
+	github.com/klauspost/compress v1.17.11
+	github.com/ulikunitz/xz v0.5.15
+)
@@ -2,16 +2,20 @@ package archive
 
 import (
 	"archive/tar"
+	"bufio"
 	"compress/gzip"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
 	"strings"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/ulikunitz/xz"
 )
 
 // ExtractTarGz 解压tar.gz文件
-func ExtractTarGz(src, dest string) error {
+func ExtractTarGz(src, dest string, opts Options) error {
 	// 打开源文件
 	file, err := os.Open(src)
 	if err != nil {
@@ -26,13 +30,62 @@ func ExtractTarGz(src, dest string) error {
 	}
 	defer gzr.Close()
 
+	return extractTar(gzr, dest, opts)
+}
+
+// ExtractTarXz 解压tar.xz文件
+func ExtractTarXz(src, dest string, opts Options) error {
+	// 打开源文件
+	file, err := os.Open(src)
+	if err != nil {
+		return fmt.Errorf("failed to open file: %w", err)
+	}
+	defer file.Close()
+
+	// 创建xz reader
+	xzr, err := xz.NewReader(bufio.NewReader(file))
+	if err != nil {
+		return fmt.Errorf("failed to create xz reader: %w", err)
+	}
+
+	return extractTar(xzr, dest, opts)
+}
+
+// ExtractTarZst 解压tar.zst文件
+func ExtractTarZst(src, dest string, opts Options) error {
+	// 打开源文件
+	file, err := os.Open(src)
+	if err != nil {
+		return fmt.Errorf("failed to open file: %w", err)
+	}
+	defer file.Close()
+
+	// 创建zstd reader
+	zr, err := zstd.NewReader(file)
+	if err != nil {
+		return fmt.Errorf("failed to create zstd reader: %w", err)
+	}
+	defer zr.Close()
+
+	return extractTar(zr, dest, opts)
+}
+
+// extractTar 从r中读取tar流并解压到dest
+// 超出解压限制或出错时删除已写入的内容
+func extractTar(r io.Reader, dest string, opts Options) (err error) {
 	// 创建tar reader
-	tr := tar.NewReader(gzr)
+	tr := tar.NewReader(r)
 
 	// 确保目标目录存在
-	if err := os.MkdirAll(dest, 0755); err != nil {
-		return fmt.Errorf("failed to create destination directory: %w", err)
+	e, err := newExtractor(dest, opts)
+	if err != nil {
+		return err
 	}
+	defer func() {
+		if err != nil {
+			e.cleanup()
+		}
+	}()
 
 	// 解压文件
 	for {
@@ -43,56 +96,68 @@ func ExtractTarGz(src, dest string) error {
 		if err != nil {
 			return fmt.Errorf("failed to read tar header: %w", err)
 		}
-
-		// 构建目标路径
-		target := filepath.Join(dest, header.Name)
-
-		// 安全检查：防止路径遍历攻击
-		// 先清理路径再进行比较，避免 "./" 等合法相对路径被误判
-		cleanTarget := filepath.Clean(target)
-		cleanDest := filepath.Clean(dest)
-		if !strings.HasPrefix(cleanTarget, cleanDest+string(os.PathSeparator)) && cleanTarget != cleanDest {
-			return fmt.Errorf("invalid file path: %s", header.Name)
+		if err := e.next(header.Name); err != nil {
+			return err
 		}
 
-		// 根据文件类型处理
+		// 根据文件类型处理，所有路径都会校验不超出目标目录（防止路径遍历攻击）
+		mode := header.FileInfo().Mode()
 		switch header.Typeflag {
 		case tar.TypeDir:
-			// 创建目录
-			if err := os.MkdirAll(cleanTarget, os.FileMode(header.Mode)); err != nil {
-				return fmt.Errorf("failed to create directory: %w", err)
-			}
-
+			err = e.dir(header.Name, mode, header.ModTime)
 		case tar.TypeReg:
-			// 创建父目录
-			if err := os.MkdirAll(filepath.Dir(cleanTarget), 0755); err != nil {
-				return fmt.Errorf("failed to create parent directory: %w", err)
-			}
-
-			// 创建文件
-			outFile, err := os.OpenFile(cleanTarget, os.O_CREATE|os.O_RDWR, os.FileMode(header.Mode))
-			if err != nil {
-				return fmt.Errorf("failed to create file: %w", err)
-			}
-
-			// 复制内容
-			if _, err := io.Copy(outFile, tr); err != nil {
-				outFile.Close()
-				return fmt.Errorf("failed to write file: %w", err)
-			}
-			outFile.Close()
-
+			err = e.file(header.Name, tr, mode, header.ModTime)
+		case tar.TypeSymlink:
+			err = e.symlink(header.Name, header.Linkname)
+		case tar.TypeLink:
+			err = e.hardlink(header.Name, header.Linkname)
 		default:
 			// 跳过不支持的文件类型
 			continue
 		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return e.finish()
+}
+
+// writeFile 创建父目录并将r的内容写入path，已存在的文件会被截断
+// max不小于0时最多读取max+1字节，调用方根据返回的写入字节数判断是否超限
+func writeFile(path string, r io.Reader, mode os.FileMode, max int64) (int64, error) {
+	// 创建父目录
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return 0, fmt.Errorf("failed to create parent directory: %w", err)
 	}
 
-	return nil
+	// 创建文件
+	outFile, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create file: %w", err)
+	}
+
+	if max >= 0 {
+		r = io.LimitReader(r, max+1)
+	}
+
+	// 复制内容
+	n, err := io.Copy(outFile, r)
+	if err != nil {
+		outFile.Close()
+		return n, fmt.Errorf("failed to write file: %w", err)
+	}
+
+	return n, outFile.Close()
 }
 
 // CreateTarGz 创建tar.gz文件
 func CreateTarGz(src, dest string, excludePatterns []string) error {
+	return CreateArchive(src, dest, FormatTarGz, excludePatterns)
+}
+
+// CreateArchive 按指定格式（tar.gz/tar.xz/tar.zst）将src目录打包为dest
+func CreateArchive(src, dest string, format Format, excludePatterns []string) error {
 	// 创建目标文件
 	file, err := os.Create(dest)
 	if err != nil {
@@ -100,16 +165,24 @@ func CreateTarGz(src, dest string, excludePatterns []string) error {
 	}
 	defer file.Close()
 
-	// 创建gzip writer
-	gzw := gzip.NewWriter(file)
-	defer gzw.Close()
+	// 创建压缩writer
+	cw, err := newCompressWriter(file, format)
+	if err != nil {
+		return err
+	}
+	// 出错返回时同样关闭压缩writer，释放zstd/xz编码器占用的资源
+	cwClosed := false
+	defer func() {
+		if !cwClosed {
+			cw.Close()
+		}
+	}()
 
 	// 创建tar writer
-	tw := tar.NewWriter(gzw)
-	defer tw.Close()
+	tw := tar.NewWriter(cw)
 
 	// 遍历源目录
-	return filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+	err = filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
@@ -133,20 +206,29 @@ func CreateTarGz(src, dest string, excludePatterns []string) error {
 			return nil
 		}
 
+		// 符号链接保存链接目标本身，不跟随链接
+		var link string
+		if info.Mode()&os.ModeSymlink != 0 {
+			if link, err = os.Readlink(path); err != nil {
+				return err
+			}
+			link = filepath.ToSlash(link)
+		}
+
 		// 创建tar header
-		header, err := tar.FileInfoHeader(info, "")
+		header, err := tar.FileInfoHeader(info, link)
 		if err != nil {
 			return err
 		}
-		header.Name = relPath
+		header.Name = filepath.ToSlash(relPath)
 
 		// 写入header
 		if err := tw.WriteHeader(header); err != nil {
 			return err
 		}
 
-		// 如果是文件，写入内容
-		if !info.IsDir() {
+		// 如果是普通文件，写入内容
+		if info.Mode().IsRegular() {
 			data, err := os.Open(path)
 			if err != nil {
 				return err
@@ -160,6 +242,39 @@ func CreateTarGz(src, dest string, excludePatterns []string) error {
 
 		return nil
 	})
+	if err != nil {
+		return err
+	}
+
+	if err := tw.Close(); err != nil {
+		return fmt.Errorf("failed to finish tar stream: %w", err)
+	}
+	cwClosed = true
+	if err := cw.Close(); err != nil {
+		return fmt.Errorf("failed to finish compression: %w", err)
+	}
+	return file.Close()
+}
+
+// newCompressWriter 创建tar包外层的压缩writer
+func newCompressWriter(w io.Writer, format Format) (io.WriteCloser, error) {
+	switch format {
+	case FormatTarGz:
+		return gzip.NewWriter(w), nil
+	case FormatTarXz:
+		xzw, err := xz.NewWriter(w)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create xz writer: %w", err)
+		}
+		return xzw, nil
+	case FormatTarZst:
+		zw, err := zstd.NewWriter(w)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create zstd writer: %w", err)
+		}
+		return zw, nil
+	}
+	return nil, fmt.Errorf("%w: cannot create %s archive", ErrUnsupportedFormat, format)
 }
 
 // shouldExclude 检查文件是否应该被排除
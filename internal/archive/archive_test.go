@@ -0,0 +1,442 @@
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/ulikunitz/xz"
+)
+
+// writeZip 创建包含指定文件的zip包
+func writeZip(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "package.bin")
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	zw := zip.NewWriter(file)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(content))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestDetectFormat(t *testing.T) {
+	zipPath := writeZip(t, map[string]string{"app": "binary"})
+
+	srcDir := t.TempDir()
+	os.WriteFile(filepath.Join(srcDir, "app"), []byte("binary"), 0755)
+	tarPath := filepath.Join(t.TempDir(), "package")
+	if err := CreateTarGz(srcDir, tarPath, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		name     string
+		path     string
+		hint     string
+		expected Format
+	}{
+		{name: "hint", path: "update.bin", hint: "ZIP", expected: FormatZip},
+		{name: "extension", path: "update.TGZ", expected: FormatTarGz},
+		{name: "zip magic", path: zipPath, expected: FormatZip},
+		{name: "gzip magic", path: tarPath, expected: FormatTarGz},
+		{name: "unknown hint falls back to magic", path: zipPath, hint: "rar", expected: FormatZip},
+		{name: "gzip hint with tar content", path: tarPath, hint: "gzip", expected: FormatTarGz},
+		{name: "raw hint with archive content", path: zipPath, hint: "raw", expected: FormatZip},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			format, err := DetectFormat(tc.path, tc.hint)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if format != tc.expected {
+				t.Errorf("Expected %s, got %s", tc.expected, format)
+			}
+		})
+	}
+}
+
+func TestBareCompressionNamesAreSingleFiles(t *testing.T) {
+	// 单独的压缩算法名称一律表示压缩的单个文件，带tar的名称表示tar包
+	testCases := map[string]Format{
+		"gz": FormatGzip, "gzip": FormatGzip, "xz": FormatXz, "zst": FormatZst, "zstd": FormatZst,
+		"tar.gz": FormatTarGz, "tgz": FormatTarGz, "tar.xz": FormatTarXz, "txz": FormatTarXz,
+		"tar.zst": FormatTarZst, "tzst": FormatTarZst,
+	}
+	for name, expected := range testCases {
+		format, err := ParseFormat(name)
+		if err != nil || format != expected {
+			t.Errorf("ParseFormat(%q): expected %s, got %s (%v)", name, expected, format, err)
+		}
+		if bare := !strings.HasPrefix(name, "t"); format.IsSingleFile() != bare {
+			t.Errorf("ParseFormat(%q): expected IsSingleFile %v for %s", name, bare, format)
+		}
+	}
+
+	// 内容为单个文件时按单文件解压，内容为tar包时识别为对应的tar包格式
+	writers := map[Format]func(io.Writer) (io.WriteCloser, error){
+		FormatGzip: func(w io.Writer) (io.WriteCloser, error) { return gzip.NewWriter(w), nil },
+		FormatXz:   func(w io.Writer) (io.WriteCloser, error) { return xz.NewWriter(w) },
+		FormatZst:  func(w io.Writer) (io.WriteCloser, error) { return zstd.NewWriter(w) },
+	}
+	for format, newWriter := range writers {
+		t.Run(string(format), func(t *testing.T) {
+			var buf bytes.Buffer
+			w, err := newWriter(&buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			w.Write([]byte("binary"))
+			w.Close()
+
+			path := filepath.Join(t.TempDir(), "app."+string(format))
+			if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+				t.Fatal(err)
+			}
+			for _, hint := range []string{string(format), ""} {
+				if detected, err := DetectFormat(path, hint); err != nil || detected != format {
+					t.Errorf("DetectFormat(hint %q): expected %s, got %s (%v)", hint, format, detected, err)
+				}
+			}
+
+			dest := filepath.Join(t.TempDir(), "app")
+			if err := ExtractFile(path, dest, format, 0755, Options{}); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if content, _ := os.ReadFile(dest); string(content) != "binary" {
+				t.Errorf("Expected extracted file to contain %q, got %q", "binary", content)
+			}
+
+			tarPath := filepath.Join(t.TempDir(), "package")
+			if err := CreateArchive(filepath.Dir(dest), tarPath, tarFormats[format], nil); err != nil {
+				t.Fatal(err)
+			}
+			if detected, err := DetectFormat(tarPath, string(format)); err != nil || detected != tarFormats[format] {
+				t.Errorf("Expected %s hint with tar content to be %s, got %s (%v)", format, tarFormats[format], detected, err)
+			}
+		})
+	}
+}
+
+func TestExtractZip(t *testing.T) {
+	path := writeZip(t, map[string]string{
+		"app":             "binary",
+		"docs/README.md":  "readme",
+		"./conf/app.conf": "conf",
+	})
+
+	dest := t.TempDir()
+	if err := Extract(path, dest, FormatZip, Options{}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	for name, expected := range map[string]string{"app": "binary", "docs/README.md": "readme", "conf/app.conf": "conf"} {
+		content, err := os.ReadFile(filepath.Join(dest, name))
+		if err != nil || string(content) != expected {
+			t.Errorf("Expected %s to contain %q, got %q (%v)", name, expected, content, err)
+		}
+	}
+}
+
+func TestExtractZipRejectsPathTraversal(t *testing.T) {
+	path := writeZip(t, map[string]string{"../escape.txt": "evil"})
+
+	root := t.TempDir()
+	dest := filepath.Join(root, "dest")
+	if err := ExtractZip(path, dest, Options{}); err == nil {
+		t.Fatal("Expected error for path traversal entry")
+	}
+	if _, err := os.Stat(filepath.Join(root, "escape.txt")); !os.IsNotExist(err) {
+		t.Error("Expected file outside destination not to be created")
+	}
+}
+
+func TestCreateArchiveRoundTrip(t *testing.T) {
+	src := t.TempDir()
+	os.MkdirAll(filepath.Join(src, "lib"), 0755)
+	os.WriteFile(filepath.Join(src, "app"), []byte("binary"), 0755)
+	os.WriteFile(filepath.Join(src, "lib", "data.bin"), []byte("data"), 0644)
+	os.WriteFile(filepath.Join(src, "config.yaml"), []byte("config"), 0644)
+
+	for _, format := range []Format{FormatTarGz, FormatTarXz, FormatTarZst} {
+		t.Run(string(format), func(t *testing.T) {
+			// 不带扩展名，验证通过文件头识别格式
+			path := filepath.Join(t.TempDir(), "backup")
+			if err := CreateArchive(src, path, format, []string{"config.yaml"}); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+
+			detected, err := DetectFormat(path, "")
+			if err != nil || detected != format {
+				t.Fatalf("Expected format %s, got %s (%v)", format, detected, err)
+			}
+
+			dest := t.TempDir()
+			if err := Extract(path, dest, detected, Options{}); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+
+			for name, expected := range map[string]string{"app": "binary", "lib/data.bin": "data"} {
+				content, err := os.ReadFile(filepath.Join(dest, name))
+				if err != nil || string(content) != expected {
+					t.Errorf("Expected %s to contain %q, got %q (%v)", name, expected, content, err)
+				}
+			}
+			if _, err := os.Stat(filepath.Join(dest, "config.yaml")); !os.IsNotExist(err) {
+				t.Error("Expected excluded file not to be archived")
+			}
+		})
+	}
+}
+
+// tarEntry 测试用tar条目
+type tarEntry struct {
+	header  tar.Header
+	content string
+}
+
+// writeTarGz 创建包含指定条目的tar.gz包
+func writeTarGz(t *testing.T, entries []tarEntry) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "package.tar.gz")
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	gzw := gzip.NewWriter(file)
+	tw := tar.NewWriter(gzw)
+	for _, entry := range entries {
+		header := entry.header
+		header.Size = int64(len(entry.content))
+		if err := tw.WriteHeader(&header); err != nil {
+			t.Fatal(err)
+		}
+		tw.Write([]byte(entry.content))
+	}
+	tw.Close()
+	gzw.Close()
+	return path
+}
+
+func TestExtractTarLinksAndModes(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("symlinks and unix permissions are not portable to windows")
+	}
+
+	mtime := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	path := writeTarGz(t, []tarEntry{
+		{header: tar.Header{Name: "lib/", Typeflag: tar.TypeDir, Mode: 0755, ModTime: mtime}},
+		{header: tar.Header{Name: "lib/libfoo.so.1", Typeflag: tar.TypeReg, Mode: 04755, ModTime: mtime}, content: "library"},
+		{header: tar.Header{Name: "lib/libfoo.so", Typeflag: tar.TypeSymlink, Linkname: "libfoo.so.1"}},
+		{header: tar.Header{Name: "bin/libfoo", Typeflag: tar.TypeLink, Linkname: "lib/libfoo.so.1"}},
+	})
+
+	for _, allowSetuid := range []bool{false, true} {
+		dest := t.TempDir()
+		if err := ExtractTarGz(path, dest, Options{AllowSetuid: allowSetuid}); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		link, err := os.Readlink(filepath.Join(dest, "lib", "libfoo.so"))
+		if err != nil || link != "libfoo.so.1" {
+			t.Errorf("Expected symlink to libfoo.so.1, got %q (%v)", link, err)
+		}
+
+		library, _ := os.Stat(filepath.Join(dest, "lib", "libfoo.so.1"))
+		hardlink, _ := os.Stat(filepath.Join(dest, "bin", "libfoo"))
+		if !os.SameFile(library, hardlink) {
+			t.Error("Expected hardlink to share the library file")
+		}
+		if !library.ModTime().Equal(mtime) {
+			t.Errorf("Expected mtime %v, got %v", mtime, library.ModTime())
+		}
+		if setuid := library.Mode()&os.ModeSetuid != 0; setuid != allowSetuid {
+			t.Errorf("Expected setuid=%v, got mode %v", allowSetuid, library.Mode())
+		}
+		if dir, _ := os.Stat(filepath.Join(dest, "lib")); !dir.ModTime().Equal(mtime) {
+			t.Errorf("Expected directory mtime %v, got %v", mtime, dir.ModTime())
+		}
+	}
+}
+
+func TestExtractTarRejectsEscapingLinks(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("symlinks are not portable to windows")
+	}
+
+	testCases := []struct {
+		name    string
+		entries []tarEntry
+	}{
+		{name: "absolute symlink", entries: []tarEntry{
+			{header: tar.Header{Name: "passwd", Typeflag: tar.TypeSymlink, Linkname: "/etc/passwd"}},
+		}},
+		{name: "parent symlink", entries: []tarEntry{
+			{header: tar.Header{Name: "lib/up", Typeflag: tar.TypeSymlink, Linkname: "../../outside"}},
+		}},
+		{name: "chained symlinks", entries: []tarEntry{
+			{header: tar.Header{Name: "a", Typeflag: tar.TypeSymlink, Linkname: "."}},
+			{header: tar.Header{Name: "a/up", Typeflag: tar.TypeSymlink, Linkname: ".."}},
+		}},
+		{name: "write through symlink", entries: []tarEntry{
+			{header: tar.Header{Name: "dir", Typeflag: tar.TypeSymlink, Linkname: "."}},
+			{header: tar.Header{Name: "dir/../escape.txt", Typeflag: tar.TypeReg, Mode: 0644}, content: "evil"},
+		}},
+		{name: "symlink retargeted by later entry", entries: []tarEntry{
+			{header: tar.Header{Name: "d", Typeflag: tar.TypeReg, Mode: 0644}},
+			{header: tar.Header{Name: "up", Typeflag: tar.TypeSymlink, Linkname: "d/.."}},
+			{header: tar.Header{Name: "d", Typeflag: tar.TypeSymlink, Linkname: "."}},
+		}},
+		{name: "hardlink outside", entries: []tarEntry{
+			{header: tar.Header{Name: "shadow", Typeflag: tar.TypeLink, Linkname: "../outside"}},
+		}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			root := t.TempDir()
+			os.WriteFile(filepath.Join(root, "outside"), []byte("secret"), 0644)
+
+			dest := filepath.Join(root, "dest")
+			if err := ExtractTarGz(writeTarGz(t, tc.entries), dest, Options{}); err == nil {
+				t.Fatal("Expected error for escaping link")
+			}
+			if _, err := os.Stat(filepath.Join(root, "escape.txt")); !os.IsNotExist(err) {
+				t.Error("Expected file outside destination not to be created")
+			}
+		})
+	}
+}
+
+func TestCreateArchiveSymlinkRoundTrip(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("symlinks are not portable to windows")
+	}
+
+	src := t.TempDir()
+	os.MkdirAll(filepath.Join(src, "lib"), 0755)
+	os.WriteFile(filepath.Join(src, "lib", "libfoo.so.1"), []byte("library"), 0755)
+	os.Symlink("libfoo.so.1", filepath.Join(src, "lib", "libfoo.so"))
+	os.Symlink("lib", filepath.Join(src, "libdir"))
+
+	path := filepath.Join(t.TempDir(), "backup.tar.gz")
+	if err := CreateTarGz(src, path, nil); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	dest := t.TempDir()
+	if err := ExtractTarGz(path, dest, Options{}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	for name, expected := range map[string]string{"lib/libfoo.so": "libfoo.so.1", "libdir": "lib"} {
+		link, err := os.Readlink(filepath.Join(dest, name))
+		if err != nil || link != expected {
+			t.Errorf("Expected %s to link to %s, got %q (%v)", name, expected, link, err)
+		}
+	}
+}
+
+func TestExtractLimits(t *testing.T) {
+	testCases := []struct {
+		name    string
+		opts    Options
+		entries []tarEntry
+	}{
+		{name: "total size", opts: Options{MaxTotalSize: 10}, entries: []tarEntry{
+			{header: tar.Header{Name: "a", Typeflag: tar.TypeReg, Mode: 0644}, content: "123456"},
+			{header: tar.Header{Name: "b", Typeflag: tar.TypeReg, Mode: 0644}, content: "123456"},
+		}},
+		{name: "file size", opts: Options{MaxFileSize: 1024}, entries: []tarEntry{
+			{header: tar.Header{Name: "dir/zeros", Typeflag: tar.TypeReg, Mode: 0644}, content: string(make([]byte, 1<<20))},
+		}},
+		{name: "entry count", opts: Options{MaxEntries: 2}, entries: []tarEntry{
+			{header: tar.Header{Name: "a", Typeflag: tar.TypeReg, Mode: 0644}},
+			{header: tar.Header{Name: "b", Typeflag: tar.TypeReg, Mode: 0644}},
+			{header: tar.Header{Name: "c", Typeflag: tar.TypeReg, Mode: 0644}},
+		}},
+		{name: "path depth", opts: Options{MaxDepth: 3}, entries: []tarEntry{
+			{header: tar.Header{Name: "a/b/c/d", Typeflag: tar.TypeReg, Mode: 0644}},
+		}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			dest := filepath.Join(t.TempDir(), "dest")
+			err := ExtractTarGz(writeTarGz(t, tc.entries), dest, tc.opts)
+			if !errors.Is(err, ErrLimitExceeded) {
+				t.Fatalf("Expected ErrLimitExceeded, got %v", err)
+			}
+			if _, err := os.Stat(dest); !os.IsNotExist(err) {
+				t.Error("Expected partially extracted files to be removed")
+			}
+		})
+	}
+
+	// 负数表示不限制
+	dest := t.TempDir()
+	entries := testCases[0].entries
+	if err := ExtractTarGz(writeTarGz(t, entries), dest, Options{MaxTotalSize: -1}); err != nil {
+		t.Errorf("Expected no error with unlimited total size, got %v", err)
+	}
+}
+
+func FuzzExtractTar(f *testing.F) {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	tw.WriteHeader(&tar.Header{Name: "dir/", Typeflag: tar.TypeDir, Mode: 0755})
+	tw.WriteHeader(&tar.Header{Name: "dir/file", Typeflag: tar.TypeReg, Mode: 0644, Size: 4})
+	tw.Write([]byte("data"))
+	tw.WriteHeader(&tar.Header{Name: "link", Typeflag: tar.TypeSymlink, Linkname: "dir/file"})
+	tw.WriteHeader(&tar.Header{Name: "hard", Typeflag: tar.TypeLink, Linkname: "dir/file"})
+	tw.WriteHeader(&tar.Header{Name: "../escape", Typeflag: tar.TypeReg, Mode: 0644})
+	tw.Close()
+	f.Add(buf.Bytes())
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		root := t.TempDir()
+		dest := filepath.Join(root, "dest")
+
+		opts := Options{MaxTotalSize: 1 << 20, MaxEntries: 100, MaxDepth: 8}
+		extractTar(bytes.NewReader(data), dest, opts)
+
+		// 无论成功与否，都不能在目标目录之外创建任何文件
+		entries, err := os.ReadDir(root)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, entry := range entries {
+			if entry.Name() != "dest" {
+				t.Fatalf("Unexpected file outside destination: %s", entry.Name())
+			}
+		}
+	})
+}
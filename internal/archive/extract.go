@@ -0,0 +1,326 @@
+package archive
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// maxLinkHops 解析路径时最多跟随的符号链接次数，防止循环链接
+const maxLinkHops = 40
+
+// Options 解压选项
+type Options struct {
+	// 是否保留setuid/setgid权限位，默认解压时清除
+	AllowSetuid bool
+	// 解压后的总大小上限（字节），0使用默认值，负数表示不限制
+	MaxTotalSize int64
+	// 单个文件大小上限（字节），0使用默认值，负数表示不限制
+	MaxFileSize int64
+	// 条目数量上限，0使用默认值，负数表示不限制
+	MaxEntries int
+	// 路径深度上限，0使用默认值，负数表示不限制
+	MaxDepth int
+}
+
+// dirEntry 需要在解压结束后设置权限和修改时间的目录
+type dirEntry struct {
+	path  string
+	mode  os.FileMode
+	mtime time.Time
+}
+
+// extractor 将归档条目安全地写入目标目录
+// 所有条目路径都会逐级解析已存在的符号链接，保证写入位置和链接目标都不超出目标目录
+type extractor struct {
+	dest   string
+	opts   Options
+	limits limits
+	links  []string
+	dirs   []dirEntry
+
+	// 已处理的条目数和已写入的字节数
+	entries int64
+	written int64
+	// 本次解压新建的路径，失败时按相反顺序删除
+	created []string
+}
+
+// newExtractor 创建解压器并确保目标目录存在
+func newExtractor(dest string, opts Options) (*extractor, error) {
+	dest, err := filepath.Abs(dest)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve destination directory: %w", err)
+	}
+
+	e := &extractor{dest: dest, opts: opts, limits: newLimits(opts)}
+	if err := e.mkdirAll(dest); err != nil {
+		return nil, fmt.Errorf("failed to create destination directory: %w", err)
+	}
+	return e, nil
+}
+
+// next 登记一个新条目并检查条目数量和路径深度限制
+func (e *extractor) next(name string) error {
+	e.entries++
+	return e.limits.checkEntry(name, e.entries)
+}
+
+// mkdirAll 创建目录及其不存在的父目录，并记录新建的目录
+func (e *extractor) mkdirAll(path string) error {
+	var missing []string
+	for dir := path; ; dir = filepath.Dir(dir) {
+		if _, err := os.Lstat(dir); err == nil {
+			break
+		}
+		missing = append(missing, dir)
+		if filepath.Dir(dir) == dir {
+			break
+		}
+	}
+
+	if err := os.MkdirAll(path, 0755); err != nil {
+		return err
+	}
+
+	for i := len(missing) - 1; i >= 0; i-- {
+		e.created = append(e.created, missing[i])
+	}
+	return nil
+}
+
+// cleanup 删除本次解压新建的文件、链接和目录（被替换的已有文件无法恢复）
+func (e *extractor) cleanup() {
+	for i := len(e.created) - 1; i >= 0; i-- {
+		os.Remove(e.created[i])
+	}
+	e.created = nil
+}
+
+// resolve 将归档内路径解析为目标目录下的真实路径
+// 逐级跟随已存在的符号链接（followLast为false时不跟随最后一级），任何一级超出目标目录时返回错误
+func (e *extractor) resolve(name string, followLast bool) (string, error) {
+	var parts []string
+	pending := splitPath(name)
+	hops := 0
+
+	for len(pending) > 0 {
+		component := pending[0]
+		pending = pending[1:]
+
+		switch component {
+		case "", ".":
+			continue
+		case "..":
+			if len(parts) == 0 {
+				return "", fmt.Errorf("invalid file path: %s", name)
+			}
+			parts = parts[:len(parts)-1]
+			continue
+		}
+
+		if len(pending) == 0 && !followLast {
+			parts = append(parts, component)
+			break
+		}
+
+		current := filepath.Join(e.dest, filepath.Join(parts...), component)
+		info, err := os.Lstat(current)
+		if err != nil || info.Mode()&os.ModeSymlink == 0 {
+			parts = append(parts, component)
+			continue
+		}
+
+		// 跟随符号链接，链接目标按链接所在目录解析
+		hops++
+		if hops > maxLinkHops {
+			return "", fmt.Errorf("too many levels of symbolic links: %s", name)
+		}
+		link, err := os.Readlink(current)
+		if err != nil {
+			return "", fmt.Errorf("failed to read symlink: %w", err)
+		}
+		if isAbsLink(link) {
+			return "", fmt.Errorf("invalid file path: %s", name)
+		}
+		pending = append(splitPath(link), pending...)
+	}
+
+	return filepath.Join(append([]string{e.dest}, parts...)...), nil
+}
+
+// splitPath 按"/"和系统路径分隔符拆分路径
+func splitPath(path string) []string {
+	return strings.Split(filepath.ToSlash(path), "/")
+}
+
+// isAbsLink 检查链接目标是否为绝对路径
+func isAbsLink(link string) bool {
+	return filepath.IsAbs(link) || strings.HasPrefix(filepath.ToSlash(link), "/") || filepath.VolumeName(link) != ""
+}
+
+// mode 返回实际写入的权限位，未允许时清除setuid/setgid
+func (e *extractor) mode(mode os.FileMode) os.FileMode {
+	keep := os.ModePerm | os.ModeSticky
+	if e.opts.AllowSetuid {
+		keep |= os.ModeSetuid | os.ModeSetgid
+	}
+	return mode & keep
+}
+
+// dir 创建目录，权限和修改时间在finish中设置，以免只读目录影响后续条目写入
+func (e *extractor) dir(name string, mode os.FileMode, mtime time.Time) error {
+	path, err := e.resolve(name, true)
+	if err != nil {
+		return err
+	}
+
+	if err := e.mkdirAll(path); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	if path != e.dest {
+		e.dirs = append(e.dirs, dirEntry{path: path, mode: mode, mtime: mtime})
+	}
+	return nil
+}
+
+// file 写入普通文件，已存在的文件或符号链接会先被删除（不会通过链接写到其他位置）
+func (e *extractor) file(name string, r io.Reader, mode os.FileMode, mtime time.Time) error {
+	path, err := e.prepare(name)
+	if err != nil {
+		return err
+	}
+
+	e.created = append(e.created, path)
+	n, err := writeFile(path, r, 0600, e.limits.fileBudget(e.written))
+	e.written += n
+	if err != nil {
+		return err
+	}
+	if err := e.limits.checkSize(name, n, e.written); err != nil {
+		return err
+	}
+
+	// 显式设置权限，不受umask影响
+	if err := os.Chmod(path, e.mode(mode)); err != nil {
+		return fmt.Errorf("failed to set file mode: %w", err)
+	}
+	return setModTime(path, mtime)
+}
+
+// symlink 创建符号链接，链接目标必须位于目标目录内
+func (e *extractor) symlink(name, linkname string) error {
+	if linkname == "" || isAbsLink(linkname) {
+		return fmt.Errorf("invalid symlink target: %s -> %s", name, linkname)
+	}
+
+	path, err := e.prepare(name)
+	if err != nil {
+		return err
+	}
+
+	// 从链接所在目录解析目标，确认不超出目标目录
+	parent, err := filepath.Rel(e.dest, filepath.Dir(path))
+	if err != nil {
+		return err
+	}
+	if _, err := e.resolve(filepath.Join(parent, filepath.FromSlash(linkname)), true); err != nil {
+		return fmt.Errorf("invalid symlink target: %s -> %s", name, linkname)
+	}
+
+	if err := os.Symlink(filepath.FromSlash(linkname), path); err != nil {
+		return fmt.Errorf("failed to create symlink: %w", err)
+	}
+	e.created = append(e.created, path)
+	e.links = append(e.links, path)
+	return nil
+}
+
+// hardlink 创建硬链接，源文件必须是目标目录内已解压的普通文件
+func (e *extractor) hardlink(name, linkname string) error {
+	source, err := e.resolve(linkname, true)
+	if err != nil {
+		return fmt.Errorf("invalid hardlink target: %s -> %s", name, linkname)
+	}
+	if info, err := os.Lstat(source); err != nil || !info.Mode().IsRegular() {
+		return fmt.Errorf("invalid hardlink target: %s -> %s", name, linkname)
+	}
+
+	path, err := e.prepare(name)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Link(source, path); err != nil {
+		return fmt.Errorf("failed to create hardlink: %w", err)
+	}
+	e.created = append(e.created, path)
+	return nil
+}
+
+// prepare 解析条目路径，创建父目录并删除已存在的非目录文件
+func (e *extractor) prepare(name string) (string, error) {
+	path, err := e.resolve(name, false)
+	if err != nil {
+		return "", err
+	}
+	if path == e.dest {
+		return "", fmt.Errorf("invalid file path: %s", name)
+	}
+
+	if err := e.mkdirAll(filepath.Dir(path)); err != nil {
+		return "", fmt.Errorf("failed to create parent directory: %w", err)
+	}
+
+	if info, err := os.Lstat(path); err == nil {
+		if info.IsDir() {
+			return "", fmt.Errorf("cannot replace directory with file: %s", name)
+		}
+		if err := os.Remove(path); err != nil {
+			return "", fmt.Errorf("failed to remove existing file: %w", err)
+		}
+	}
+
+	return path, nil
+}
+
+// finish 校验所有符号链接仍指向目标目录内（后续条目可能改变链接路径上的目录），
+// 然后按从深到浅的顺序设置目录权限和修改时间
+func (e *extractor) finish() error {
+	for _, link := range e.links {
+		rel, err := filepath.Rel(e.dest, link)
+		if err != nil {
+			return err
+		}
+		if _, err := e.resolve(rel, true); err != nil {
+			return fmt.Errorf("symlink escapes destination: %s", rel)
+		}
+	}
+
+	for i := len(e.dirs) - 1; i >= 0; i-- {
+		dir := e.dirs[i]
+		if err := os.Chmod(dir.path, e.mode(dir.mode)); err != nil {
+			return fmt.Errorf("failed to set directory mode: %w", err)
+		}
+		if err := setModTime(dir.path, dir.mtime); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// setModTime 设置文件修改时间，mtime为零值时跳过
+func setModTime(path string, mtime time.Time) error {
+	if mtime.IsZero() {
+		return nil
+	}
+	if err := os.Chtimes(path, mtime, mtime); err != nil {
+		return fmt.Errorf("failed to set modification time: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,241 @@
+package archive
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/ulikunitz/xz"
+)
+
+// Format 更新包归档格式
+type Format string
+
+const (
+	FormatTarGz  Format = "tar.gz"  // gzip压缩的tar包
+	FormatTarXz  Format = "tar.xz"  // xz压缩的tar包
+	FormatTarZst Format = "tar.zst" // zstd压缩的tar包
+	FormatZip    Format = "zip"     // zip包
+	FormatGzip   Format = "gzip"    // gzip压缩的单个文件
+	FormatXz     Format = "xz"      // xz压缩的单个文件
+	FormatZst    Format = "zst"     // zstd压缩的单个文件
+	FormatRaw    Format = "raw"     // 未压缩的单个文件（如可执行文件）
+)
+
+// IsTar 是否为tar包格式，tar包格式可用于CreateArchive创建备份
+func (f Format) IsTar() bool {
+	return f == FormatTarGz || f == FormatTarXz || f == FormatTarZst
+}
+
+// IsSingleFile 是否为单文件格式，单文件格式需使用ExtractFile解压到指定文件
+func (f Format) IsSingleFile() bool {
+	return f == FormatGzip || f == FormatXz || f == FormatZst || f == FormatRaw
+}
+
+// tarFormats 单文件压缩格式到内容为tar包时对应的tar包格式的映射
+var tarFormats = map[Format]Format{
+	FormatGzip: FormatTarGz,
+	FormatXz:   FormatTarXz,
+	FormatZst:  FormatTarZst,
+}
+
+// ErrUnsupportedFormat 不支持的归档格式
+var ErrUnsupportedFormat = errors.New("unsupported archive format")
+
+// formatAliases 压缩类型名称（如UpdateFile.CompressionType）到归档格式的映射
+// 单独的压缩算法名称（gz/xz/zst等）均表示压缩的单个文件，内容为tar包时由DetectFormat识别为对应的tar包格式
+var formatAliases = map[string]Format{
+	"tar.gz":  FormatTarGz,
+	"tgz":     FormatTarGz,
+	"tar.xz":  FormatTarXz,
+	"txz":     FormatTarXz,
+	"xz":      FormatXz,
+	"tar.zst": FormatTarZst,
+	"tzst":    FormatTarZst,
+	"zst":     FormatZst,
+	"zstd":    FormatZst,
+	"gzip":    FormatGzip,
+	"gz":      FormatGzip,
+	"zip":     FormatZip,
+	"raw":     FormatRaw,
+	"none":    FormatRaw,
+}
+
+// formatExtensions 可识别的归档扩展名，较长的扩展名需排在前面
+var formatExtensions = []string{".tar.gz", ".tgz", ".tar.xz", ".txz", ".tar.zst", ".tzst", ".zip", ".gz", ".xz", ".zst"}
+
+// ParseFormat 解析压缩类型名称，不区分大小写，忽略前导"."
+func ParseFormat(name string) (Format, error) {
+	name = strings.TrimPrefix(strings.ToLower(strings.TrimSpace(name)), ".")
+	if format, ok := formatAliases[name]; ok {
+		return format, nil
+	}
+	return "", fmt.Errorf("%w: %s", ErrUnsupportedFormat, name)
+}
+
+// Extension 返回文件名中可识别的归档扩展名（如".tar.gz"），无法识别时返回空字符串
+func Extension(name string) string {
+	lower := strings.ToLower(name)
+	for _, ext := range formatExtensions {
+		if strings.HasSuffix(lower, ext) {
+			return name[len(name)-len(ext):]
+		}
+	}
+	return ""
+}
+
+// DetectFormat 判断归档格式，依次使用hint（压缩类型名称）、文件扩展名和文件头魔数
+// gzip/xz/zstd压缩的单个文件会进一步检查内容是否为tar包；hint为raw但文件头是已知归档格式时以文件头为准
+func DetectFormat(path, hint string) (Format, error) {
+	format, err := ParseFormat(hint)
+	if hint == "" || err != nil {
+		format, err = ParseFormat(Extension(path))
+		if err != nil {
+			format, err = detectMagic(path)
+			if err != nil {
+				return "", err
+			}
+		}
+	}
+
+	if format == FormatRaw {
+		if magic, err := detectMagic(path); err == nil {
+			format = magic
+		}
+	}
+
+	if tarFormat, ok := tarFormats[format]; ok && isTar(path, format) {
+		format = tarFormat
+	}
+
+	return format, nil
+}
+
+// detectMagic 根据文件头魔数判断归档格式
+func detectMagic(path string) (Format, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to open file: %w", err)
+	}
+	defer file.Close()
+
+	header := make([]byte, 6)
+	n, err := io.ReadFull(file, header)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return "", fmt.Errorf("failed to read file header: %w", err)
+	}
+	header = header[:n]
+
+	switch {
+	case bytes.HasPrefix(header, []byte{0x1f, 0x8b}):
+		return FormatGzip, nil
+	case bytes.HasPrefix(header, []byte("PK\x03\x04")), bytes.HasPrefix(header, []byte("PK\x05\x06")):
+		return FormatZip, nil
+	case bytes.HasPrefix(header, []byte{0xfd, '7', 'z', 'X', 'Z', 0x00}):
+		return FormatXz, nil
+	case bytes.HasPrefix(header, []byte{0x28, 0xb5, 0x2f, 0xfd}):
+		return FormatZst, nil
+	}
+
+	return "", ErrUnsupportedFormat
+}
+
+// isTar 检查单文件压缩格式的文件解压后的内容是否为tar包
+func isTar(path string, format Format) bool {
+	file, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+
+	r, closeReader, err := newDecompressReader(file, format)
+	if err != nil {
+		return false
+	}
+	defer closeReader()
+
+	_, err = tar.NewReader(r).Next()
+	return err == nil
+}
+
+// newDecompressReader 返回单文件格式的解压reader，使用完毕后调用返回的关闭函数
+func newDecompressReader(r io.Reader, format Format) (io.Reader, func(), error) {
+	switch format {
+	case FormatRaw:
+		return r, func() {}, nil
+	case FormatGzip:
+		gzr, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create gzip reader: %w", err)
+		}
+		return gzr, func() { gzr.Close() }, nil
+	case FormatXz:
+		xzr, err := xz.NewReader(bufio.NewReader(r))
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create xz reader: %w", err)
+		}
+		return xzr, func() {}, nil
+	case FormatZst:
+		zr, err := zstd.NewReader(r)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create zstd reader: %w", err)
+		}
+		return zr, zr.Close, nil
+	}
+	return nil, nil, fmt.Errorf("%w: %s is not a single-file format", ErrUnsupportedFormat, format)
+}
+
+// Extract 按指定格式将src解压到dest目录
+func Extract(src, dest string, format Format, opts Options) error {
+	switch format {
+	case FormatTarGz:
+		return ExtractTarGz(src, dest, opts)
+	case FormatTarXz:
+		return ExtractTarXz(src, dest, opts)
+	case FormatTarZst:
+		return ExtractTarZst(src, dest, opts)
+	case FormatZip:
+		return ExtractZip(src, dest, opts)
+	}
+	if format.IsSingleFile() {
+		return fmt.Errorf("%w: %s must be extracted with ExtractFile", ErrUnsupportedFormat, format)
+	}
+	return fmt.Errorf("%w: %s", ErrUnsupportedFormat, format)
+}
+
+// ExtractFile 将单文件格式的src解压（或复制）为destFile，并设置文件权限
+// 解压后的大小受opts中单文件和总大小限制约束，超限时删除已写入的文件
+func ExtractFile(src, destFile string, format Format, mode os.FileMode, opts Options) error {
+	file, err := os.Open(src)
+	if err != nil {
+		return fmt.Errorf("failed to open file: %w", err)
+	}
+	defer file.Close()
+
+	r, closeReader, err := newDecompressReader(file, format)
+	if err != nil {
+		return err
+	}
+	defer closeReader()
+
+	limits := newLimits(opts)
+	n, err := writeFile(destFile, r, mode, limits.fileBudget(0))
+	if err == nil {
+		err = limits.checkSize(filepath.Base(destFile), n, n)
+	}
+	if err != nil {
+		os.Remove(destFile)
+		return err
+	}
+
+	// 显式设置权限，不受umask影响
+	return os.Chmod(destFile, mode)
+}
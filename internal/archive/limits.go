@@ -0,0 +1,94 @@
+package archive
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+)
+
+// 默认解压限制，防止解压炸弹耗尽磁盘或inode
+const (
+	DefaultMaxTotalSize int64 = 8 << 30 // 解压后总大小上限 8GiB
+	DefaultMaxFileSize  int64 = 4 << 30 // 单个文件大小上限 4GiB
+	DefaultMaxEntries         = 100000  // 条目数量上限
+	DefaultMaxDepth           = 64      // 路径深度上限
+)
+
+// ErrLimitExceeded 超出解压限制
+var ErrLimitExceeded = errors.New("extraction limit exceeded")
+
+// limits 生效的解压限制，负数表示不限制
+type limits struct {
+	totalSize int64
+	fileSize  int64
+	entries   int64
+	depth     int64
+}
+
+// newLimits 根据选项计算生效的限制：0使用默认值，负数表示不限制
+func newLimits(opts Options) limits {
+	return limits{
+		totalSize: effectiveLimit(opts.MaxTotalSize, DefaultMaxTotalSize),
+		fileSize:  effectiveLimit(opts.MaxFileSize, DefaultMaxFileSize),
+		entries:   effectiveLimit(int64(opts.MaxEntries), DefaultMaxEntries),
+		depth:     effectiveLimit(int64(opts.MaxDepth), DefaultMaxDepth),
+	}
+}
+
+// effectiveLimit 返回生效的限制值
+func effectiveLimit(value, defaultValue int64) int64 {
+	switch {
+	case value == 0:
+		return defaultValue
+	case value < 0:
+		return -1
+	}
+	return value
+}
+
+// fileBudget 返回下一个文件最多可写入的字节数，-1表示不限制
+func (l limits) fileBudget(written int64) int64 {
+	budget := l.fileSize
+	if l.totalSize >= 0 {
+		remaining := l.totalSize - written
+		if remaining < 0 {
+			remaining = 0
+		}
+		if budget < 0 || remaining < budget {
+			budget = remaining
+		}
+	}
+	return budget
+}
+
+// checkSize 检查写入n字节后的文件大小和累计大小是否超限
+func (l limits) checkSize(name string, n, total int64) error {
+	if l.fileSize >= 0 && n > l.fileSize {
+		return fmt.Errorf("%w: file %s exceeds %d bytes", ErrLimitExceeded, name, l.fileSize)
+	}
+	if l.totalSize >= 0 && total > l.totalSize {
+		return fmt.Errorf("%w: total size exceeds %d bytes", ErrLimitExceeded, l.totalSize)
+	}
+	return nil
+}
+
+// checkEntry 检查条目数量和路径深度是否超限，count为包含当前条目在内的条目数
+func (l limits) checkEntry(name string, count int64) error {
+	if l.entries >= 0 && count > l.entries {
+		return fmt.Errorf("%w: more than %d entries", ErrLimitExceeded, l.entries)
+	}
+	if l.depth >= 0 && pathDepth(name) > l.depth {
+		return fmt.Errorf("%w: path %s is deeper than %d levels", ErrLimitExceeded, name, l.depth)
+	}
+	return nil
+}
+
+// pathDepth 计算归档内路径的层级数
+func pathDepth(name string) int64 {
+	cleaned := strings.Trim(path.Clean("/"+strings.ReplaceAll(name, "\\", "/")), "/")
+	if cleaned == "" {
+		return 0
+	}
+	return int64(strings.Count(cleaned, "/") + 1)
+}
@@ -0,0 +1,69 @@
+package archive
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"io/fs"
+)
+
+// ExtractZip 解压zip文件，超出解压限制或出错时删除已写入的内容
+func ExtractZip(src, dest string, opts Options) (err error) {
+	zr, err := zip.OpenReader(src)
+	if err != nil {
+		return fmt.Errorf("failed to open zip file: %w", err)
+	}
+	defer zr.Close()
+
+	// 确保目标目录存在
+	e, err := newExtractor(dest, opts)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			e.cleanup()
+		}
+	}()
+
+	for _, file := range zr.File {
+		if err := e.next(file.Name); err != nil {
+			return err
+		}
+		if err := extractZipEntry(e, file); err != nil {
+			return err
+		}
+	}
+
+	return e.finish()
+}
+
+// extractZipEntry 解压单个zip条目，所有路径都会校验不超出目标目录（防止路径遍历攻击）
+func extractZipEntry(e *extractor, file *zip.File) error {
+	mode := file.Mode()
+	switch {
+	case mode.IsDir():
+		return e.dir(file.Name, mode, file.Modified)
+
+	case mode.IsRegular(), mode&fs.ModeSymlink != 0:
+		rc, err := file.Open()
+		if err != nil {
+			return fmt.Errorf("failed to open zip entry %s: %w", file.Name, err)
+		}
+		defer rc.Close()
+
+		if mode.IsRegular() {
+			return e.file(file.Name, rc, mode, file.Modified)
+		}
+
+		// zip中的符号链接以文件内容保存链接目标
+		target, err := io.ReadAll(io.LimitReader(rc, 4096))
+		if err != nil {
+			return fmt.Errorf("failed to read zip entry %s: %w", file.Name, err)
+		}
+		return e.symlink(file.Name, string(target))
+	}
+
+	// 跳过不支持的文件类型
+	return nil
+}
@@ -1,31 +1,68 @@
 package http
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
 	"os"
+	"sync/atomic"
 	"time"
 )
 
 // ProgressCallback 下载进度回调函数类型  
 type ProgressCallback func(downloaded, total int64)
 
+// Options HTTP客户端配置
+type Options struct {
+	// API请求超时时间（整个请求的耗时上限，不作用于文件下载）
+	Timeout time.Duration
+	// 建立连接（含TLS握手）的超时时间
+	ConnectTimeout time.Duration
+	// 下载停滞超时时间，超过该时长未收到任何数据时中止下载
+	StallTimeout time.Duration
+	// 重试策略
+	Retry RetryPolicy
+}
+
 // Client HTTP客户端
 type Client struct {
-	baseURL    string
-	httpClient *http.Client
+	baseURL        string
+	apiClient      *http.Client
+	downloadClient *http.Client
+	stallTimeout   time.Duration
+	retry          RetryPolicy
 }
 
 // NewClient 创建新的HTTP客户端
-func NewClient(baseURL string, timeout time.Duration) *Client {
+func NewClient(baseURL string, opts Options) *Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if opts.ConnectTimeout > 0 {
+		transport.DialContext = (&net.Dialer{
+			Timeout:   opts.ConnectTimeout,
+			KeepAlive: 30 * time.Second,
+		}).DialContext
+		transport.TLSHandshakeTimeout = opts.ConnectTimeout
+	}
+	if opts.StallTimeout > 0 {
+		transport.ResponseHeaderTimeout = opts.StallTimeout
+	}
+
 	return &Client{
 		baseURL: baseURL,
-		httpClient: &http.Client{
-			Timeout: timeout,
+		apiClient: &http.Client{
+			Transport: transport,
+			Timeout:   opts.Timeout,
+		},
+		// 下载不设置整体超时，仅通过停滞超时中止长时间无数据的传输
+		downloadClient: &http.Client{
+			Transport: transport,
 		},
+		stallTimeout: opts.StallTimeout,
+		retry:        opts.Retry,
 	}
 }
 
@@ -34,13 +71,36 @@ func (c *Client) Get(ctx context.Context, path string, result interface{}) error
 	return c.GetWithAuth(ctx, path, "", result)
 }
 
-// GetWithAuth 发送带认证的GET请求
+// GetWithAuth 发送带认证的GET请求，失败时按重试策略重试
 func (c *Client) GetWithAuth(ctx context.Context, path string, apiKey string, result interface{}) error {
+	return c.withRetry(ctx, func() error {
+		return c.requestOnce(ctx, http.MethodGet, path, apiKey, nil, result)
+	})
+}
+
+// PostWithAuth 发送带认证的POST请求，请求体为body的JSON编码，失败时按重试策略重试
+func (c *Client) PostWithAuth(ctx context.Context, path string, apiKey string, body interface{}, result interface{}) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to encode request: %w", err)
+	}
+
+	return c.withRetry(ctx, func() error {
+		return c.requestOnce(ctx, http.MethodPost, path, apiKey, data, result)
+	})
+}
+
+// requestOnce 发送一次带认证的API请求，body不为nil时作为请求体发送
+func (c *Client) requestOnce(ctx context.Context, method, path string, apiKey string, body []byte, result interface{}) error {
 	url := c.baseURL + path
-	
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, reader)
 	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
+		return permanent(fmt.Errorf("failed to create request: %w", err))
 	}
 
 	req.Header.Set("Content-Type", "application/json")
@@ -51,20 +111,20 @@ func (c *Client) GetWithAuth(ctx context.Context, path string, apiKey string, re
 		req.Header.Set("Authorization", "Bearer "+apiKey)
 	}
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.apiClient.Do(req)
 	if err != nil {
 		return fmt.Errorf("failed to send request: %w", err)
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
 		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("HTTP %d: %s", resp.StatusCode, string(body))
+		return newStatusError(resp, string(body))
 	}
 
-	if result != nil {
+	if result != nil && resp.StatusCode != http.StatusNoContent {
 		if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
-			return fmt.Errorf("failed to decode response: %w", err)
+			return permanent(fmt.Errorf("failed to decode response: %w", err))
 		}
 	}
 
@@ -77,10 +137,41 @@ func (c *Client) DownloadFile(ctx context.Context, url, destPath string, callbac
 }
 
 // DownloadWithAuth 带认证的下载文件
+// 若destPath已存在未完成的部分内容，且服务端支持Range请求，则从断点继续下载；
+// 通过If-Range携带上次响应的ETag/Last-Modified，文件在服务端发生变化时自动从头下载。
+// 失败时按重试策略重试，每次重试都会从已下载的位置继续
 func (c *Client) DownloadWithAuth(ctx context.Context, url, apiKey, destPath string, expectedSize int64, callback ProgressCallback) error {
+	return c.withRetry(ctx, func() error {
+		return c.downloadOnce(ctx, url, apiKey, destPath, expectedSize, callback)
+	})
+}
+
+// downloadOnce 执行一次下载请求
+func (c *Client) downloadOnce(ctx context.Context, url, apiKey, destPath string, expectedSize int64, callback ProgressCallback) error {
+	// 检查可续传的部分内容
+	var offset int64
+	meta := loadPartialMeta(destPath)
+	if info, err := os.Stat(destPath); err == nil && meta != nil && meta.URL == url && meta.validator() != "" {
+		offset = info.Size()
+	}
+
+	// 停滞检测：每收到数据就重置计时器，超时未收到数据则取消请求
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var stalled atomic.Bool
+	var stallTimer *time.Timer
+	if c.stallTimeout > 0 {
+		stallTimer = time.AfterFunc(c.stallTimeout, func() {
+			stalled.Store(true)
+			cancel()
+		})
+		defer stallTimer.Stop()
+	}
+
 	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
+		return permanent(fmt.Errorf("failed to create request: %w", err))
 	}
 
 	// 添加API密钥认证 - 使用Authorization Bearer格式
@@ -88,34 +179,86 @@ func (c *Client) DownloadWithAuth(ctx context.Context, url, apiKey, destPath str
 		req.Header.Set("Authorization", "Bearer "+apiKey)
 	}
 
-	resp, err := c.httpClient.Do(req)
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+		req.Header.Set("If-Range", meta.validator())
+	}
+
+	resp, err := c.downloadClient.Do(req)
 	if err != nil {
+		if stalled.Load() {
+			return c.stallError()
+		}
 		return fmt.Errorf("failed to send request: %w", err)
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("HTTP %d", resp.StatusCode)
+	flags := os.O_CREATE | os.O_WRONLY
+	switch {
+	case resp.StatusCode == http.StatusPartialContent && offset > 0:
+		// 服务端接受续传，校验返回的起始位置
+		start, err := parseContentRangeStart(resp.Header.Get("Content-Range"))
+		if err != nil || start != offset {
+			return fmt.Errorf("unexpected Content-Range: %q", resp.Header.Get("Content-Range"))
+		}
+		flags |= os.O_APPEND
+
+	case resp.StatusCode == http.StatusRequestedRangeNotSatisfiable && offset > 0:
+		// 部分内容已是完整文件
+		if expectedSize > 0 && offset == expectedSize {
+			removePartialMeta(destPath)
+			if callback != nil {
+				callback(offset, expectedSize)
+			}
+			return nil
+		}
+		// 无法续传，丢弃部分内容，重试时从头下载
+		os.Remove(destPath)
+		removePartialMeta(destPath)
+		return fmt.Errorf("HTTP %d: partial download discarded", resp.StatusCode)
+
+	case resp.StatusCode == http.StatusOK:
+		// 服务端不支持Range或文件已变化，从头下载
+		offset = 0
+		flags |= os.O_TRUNC
+
+	default:
+		return newStatusError(resp, "")
 	}
 
-	// 创建目标文件
-	out, err := os.Create(destPath)
+	// 记录续传所需的校验信息
+	if err := savePartialMeta(destPath, &partialMeta{
+		URL:          url,
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+	}); err != nil {
+		return permanent(fmt.Errorf("failed to save download state: %w", err))
+	}
+
+	// 打开目标文件
+	out, err := os.OpenFile(destPath, flags, 0644)
 	if err != nil {
-		return fmt.Errorf("failed to create file: %w", err)
+		return permanent(fmt.Errorf("failed to create file: %w", err))
 	}
 	defer out.Close()
 
 	// 获取文件大小（优先使用期望大小）
 	contentLength := resp.ContentLength
+	if contentLength >= 0 {
+		contentLength += offset
+	}
 	if expectedSize > 0 {
 		contentLength = expectedSize
 	}
 
 	// 创建进度追踪器
-	var downloaded int64
+	downloaded := offset
 	reader := &progressReader{
 		Reader: resp.Body,
 		callback: func(n int64) {
+			if stallTimer != nil {
+				stallTimer.Reset(c.stallTimeout)
+			}
 			downloaded += n
 			if callback != nil {
 				callback(downloaded, contentLength)
@@ -124,14 +267,57 @@ func (c *Client) DownloadWithAuth(ctx context.Context, url, apiKey, destPath str
 	}
 
 	// 复制数据
-	_, err = io.Copy(out, reader)
+	writer := &fileWriter{file: out}
+	_, err = io.Copy(writer, reader)
 	if err != nil {
+		// 本地写入错误（如磁盘空间不足）重试也无法恢复
+		if writer.err != nil {
+			return permanent(fmt.Errorf("failed to write file: %w", writer.err))
+		}
+		if stalled.Load() {
+			return c.stallError()
+		}
 		return fmt.Errorf("failed to download file: %w", err)
 	}
 
+	if err := out.Sync(); err != nil {
+		return permanent(fmt.Errorf("failed to sync file: %w", err))
+	}
+
+	removePartialMeta(destPath)
 	return nil
 }
 
+// fileWriter 记录写入文件时的错误，用于区分本地写入错误和读取响应时的网络错误
+type fileWriter struct {
+	file *os.File
+	err  error
+}
+
+// Write 实现io.Writer接口
+func (w *fileWriter) Write(p []byte) (int, error) {
+	n, err := w.file.Write(p)
+	if err != nil {
+		w.err = err
+	}
+	return n, err
+}
+
+// stallError 返回下载停滞错误（可重试，重试时从断点续传）
+func (c *Client) stallError() error {
+	return fmt.Errorf("download stalled: no data received for %s", c.stallTimeout)
+}
+
+// parseContentRangeStart 解析Content-Range响应头中的起始位置，如"bytes 100-199/200"
+func parseContentRangeStart(contentRange string) (int64, error) {
+	var start, end int64
+	var total string
+	if _, err := fmt.Sscanf(contentRange, "bytes %d-%d/%s", &start, &end, &total); err != nil {
+		return 0, err
+	}
+	return start, nil
+}
+
 // progressReader 带进度回调的Reader
 type progressReader struct {
 	io.Reader
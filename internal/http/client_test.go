@@ -0,0 +1,307 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDownloadWithAuthResumes(t *testing.T) {
+	payload := bytes.Repeat([]byte("0123456789"), 1000)
+	modTime := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	var requests int
+	var rangeHeader string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("ETag", `"v1"`)
+		if requests == 1 {
+			// 第一次只返回一半内容后断开连接
+			w.Header().Set("Content-Length", strconv.Itoa(len(payload)))
+			w.Write(payload[:len(payload)/2])
+			panic(http.ErrAbortHandler)
+		}
+		rangeHeader = r.Header.Get("Range")
+		http.ServeContent(w, r, "update.tar.gz", modTime, bytes.NewReader(payload))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, Options{Timeout: 10 * time.Second, Retry: RetryPolicy{MaxAttempts: 1}})
+	destPath := filepath.Join(t.TempDir(), "update.tar.gz.part")
+
+	if err := client.DownloadWithAuth(context.Background(), server.URL, "key", destPath, int64(len(payload)), nil); err == nil {
+		t.Fatal("Expected interrupted download to fail")
+	}
+
+	var lastDownloaded int64
+	err := client.DownloadWithAuth(context.Background(), server.URL, "key", destPath, int64(len(payload)), func(downloaded, total int64) {
+		lastDownloaded = downloaded
+	})
+	if err != nil {
+		t.Fatalf("Expected resumed download to succeed, got %v", err)
+	}
+
+	if rangeHeader != "bytes=5000-" {
+		t.Errorf("Expected Range request from offset 5000, got %q", rangeHeader)
+	}
+	if lastDownloaded != int64(len(payload)) {
+		t.Errorf("Expected progress to include resumed offset, got %d", lastDownloaded)
+	}
+
+	data, err := os.ReadFile(destPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, payload) {
+		t.Error("Expected resumed file to match payload")
+	}
+	if _, err := os.Stat(partialMetaPath(destPath)); !os.IsNotExist(err) {
+		t.Error("Expected resume metadata to be removed after completion")
+	}
+}
+
+func TestDownloadWithAuthRestartsWhenFileChanged(t *testing.T) {
+	payload := []byte("new content")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// 文件已变化：If-Range不匹配时返回完整内容
+		w.Header().Set("ETag", `"v2"`)
+		http.ServeContent(w, r, "update.tar.gz", time.Time{}, bytes.NewReader(payload))
+	}))
+	defer server.Close()
+
+	destPath := filepath.Join(t.TempDir(), "update.tar.gz.part")
+	os.WriteFile(destPath, []byte("old"), 0644)
+	savePartialMeta(destPath, &partialMeta{URL: server.URL, ETag: `"v1"`})
+
+	client := NewClient(server.URL, Options{Timeout: 10 * time.Second, Retry: RetryPolicy{MaxAttempts: 1}})
+	if err := client.DownloadWithAuth(context.Background(), server.URL, "", destPath, 0, nil); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	data, _ := os.ReadFile(destPath)
+	if !bytes.Equal(data, payload) {
+		t.Errorf("Expected file to be downloaded from scratch, got %q", data)
+	}
+}
+
+func newRetryClient(baseURL string, maxAttempts int) *Client {
+	return NewClient(baseURL, Options{
+		Timeout: 10 * time.Second,
+		Retry: RetryPolicy{
+			MaxAttempts:          maxAttempts,
+			BaseBackoff:          time.Millisecond,
+			MaxBackoff:           10 * time.Millisecond,
+			RetryableStatusCodes: DefaultRetryPolicy().RetryableStatusCodes,
+		},
+	})
+}
+
+func TestGetWithAuthRetriesTransientErrors(t *testing.T) {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests < 3 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.Write([]byte(`{"code":200}`))
+	}))
+	defer server.Close()
+
+	var result struct {
+		Code int `json:"code"`
+	}
+	if err := newRetryClient(server.URL, 3).GetWithAuth(context.Background(), "/check", "key", &result); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if requests != 3 || result.Code != 200 {
+		t.Errorf("Expected 3 requests and decoded result, got %d requests, code %d", requests, result.Code)
+	}
+}
+
+func TestGetWithAuthDoesNotRetryClientErrors(t *testing.T) {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	err := newRetryClient(server.URL, 5).GetWithAuth(context.Background(), "/check", "key", nil)
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusNotFound {
+		t.Fatalf("Expected 404 StatusError, got %v", err)
+	}
+	if requests != 1 {
+		t.Errorf("Expected a single request, got %d", requests)
+	}
+}
+
+func TestGetWithAuthHonoursRetryAfter(t *testing.T) {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	c := NewClient(server.URL, Options{
+		Timeout: 10 * time.Second,
+		Retry: RetryPolicy{
+			MaxAttempts:          2,
+			BaseBackoff:          time.Millisecond,
+			MaxBackoff:           time.Minute,
+			RetryableStatusCodes: DefaultRetryPolicy().RetryableStatusCodes,
+		},
+	})
+
+	start := time.Now()
+	if err := c.GetWithAuth(context.Background(), "/check", "key", nil); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Errorf("Expected to wait for Retry-After, waited %v", elapsed)
+	}
+}
+
+func TestGetWithAuthClampsRetryAfter(t *testing.T) {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests == 1 {
+			w.Header().Set("Retry-After", "3600")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	start := time.Now()
+	if err := newRetryClient(server.URL, 2).GetWithAuth(context.Background(), "/check", "key", nil); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Expected Retry-After to be clamped to MaxBackoff, waited %v", elapsed)
+	}
+}
+
+func TestGetWithAuthStopsOnContextCancel(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "60")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	if err := newRetryClient(server.URL, 10).GetWithAuth(ctx, "/check", "key", nil); err == nil {
+		t.Fatal("Expected error after context cancellation")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Expected retries to stop on cancellation, took %v", elapsed)
+	}
+}
+
+func TestDownloadWithAuthRetriesAndResumes(t *testing.T) {
+	payload := bytes.Repeat([]byte("abcdefghij"), 500)
+
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("ETag", `"v1"`)
+		if requests == 1 {
+			w.Header().Set("Content-Length", strconv.Itoa(len(payload)))
+			w.Write(payload[:1000])
+			panic(http.ErrAbortHandler)
+		}
+		http.ServeContent(w, r, "update.tar.gz", time.Time{}, bytes.NewReader(payload))
+	}))
+	defer server.Close()
+
+	destPath := filepath.Join(t.TempDir(), "update.tar.gz.part")
+	if err := newRetryClient(server.URL, 3).DownloadWithAuth(context.Background(), server.URL, "", destPath, 0, nil); err != nil {
+		t.Fatalf("Expected download to succeed after retry, got %v", err)
+	}
+
+	data, _ := os.ReadFile(destPath)
+	if !bytes.Equal(data, payload) || requests != 2 {
+		t.Errorf("Expected resumed download in 2 requests, got %d requests and %d bytes", requests, len(data))
+	}
+}
+
+func TestDownloadDoesNotRetryWriteErrors(t *testing.T) {
+	if _, err := os.Stat("/dev/full"); err != nil {
+		t.Skip("/dev/full not available")
+	}
+
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Write(bytes.Repeat([]byte("abcdefghij"), 500))
+	}))
+	defer server.Close()
+
+	// 写入/dev/full总是返回ENOSPC
+	destPath := filepath.Join(t.TempDir(), "update.tar.gz.part")
+	if err := os.Symlink("/dev/full", destPath); err != nil {
+		t.Skip(err)
+	}
+	if err := newRetryClient(server.URL, 3).DownloadWithAuth(context.Background(), server.URL, "", destPath, 0, nil); err == nil {
+		t.Fatal("Expected write error")
+	}
+	if requests != 1 {
+		t.Errorf("Expected write error not to be retried, got %d requests", requests)
+	}
+}
+
+func TestDownloadIgnoresAPITimeoutButAbortsOnStall(t *testing.T) {
+	chunk := []byte("0123456789")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		flusher := w.(http.Flusher)
+		if r.URL.Path == "/stall" {
+			w.Write(chunk)
+			flusher.Flush()
+			<-r.Context().Done()
+			return
+		}
+		// 慢速但持续的传输，总耗时超过API超时
+		for i := 0; i < 5; i++ {
+			w.Write(chunk)
+			flusher.Flush()
+			time.Sleep(50 * time.Millisecond)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, Options{
+		Timeout:      100 * time.Millisecond,
+		StallTimeout: 200 * time.Millisecond,
+		Retry:        RetryPolicy{MaxAttempts: 1},
+	})
+
+	destPath := filepath.Join(t.TempDir(), "slow.part")
+	if err := client.DownloadWithAuth(context.Background(), server.URL+"/slow", "", destPath, 0, nil); err != nil {
+		t.Fatalf("Expected slow download to succeed, got %v", err)
+	}
+
+	err := client.DownloadWithAuth(context.Background(), server.URL+"/stall", "", filepath.Join(t.TempDir(), "stall.part"), 0, nil)
+	if err == nil || !strings.Contains(err.Error(), "stalled") {
+		t.Fatalf("Expected stall error, got %v", err)
+	}
+}
@@ -0,0 +1,59 @@
+package http
+
+import (
+	"encoding/json"
+	"os"
+)
+
+// partialMeta 断点续传所需的元数据，保存在部分下载文件旁的.meta文件中
+type partialMeta struct {
+	URL          string `json:"url"`
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"lastModified,omitempty"`
+}
+
+// validator 返回用于If-Range的校验值，优先使用强ETag
+func (m *partialMeta) validator() string {
+	if m.ETag != "" && !isWeakETag(m.ETag) {
+		return m.ETag
+	}
+	return m.LastModified
+}
+
+// isWeakETag 判断是否为弱ETag（If-Range只能使用强校验值）
+func isWeakETag(etag string) bool {
+	return len(etag) >= 2 && etag[:2] == "W/"
+}
+
+// partialMetaPath 返回元数据文件路径
+func partialMetaPath(destPath string) string {
+	return destPath + ".meta"
+}
+
+// loadPartialMeta 读取元数据，不存在或无法解析时返回nil
+func loadPartialMeta(destPath string) *partialMeta {
+	data, err := os.ReadFile(partialMetaPath(destPath))
+	if err != nil {
+		return nil
+	}
+
+	var meta partialMeta
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return nil
+	}
+	return &meta
+}
+
+// savePartialMeta 保存元数据
+func savePartialMeta(destPath string, meta *partialMeta) error {
+	data, err := json.Marshal(meta)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(partialMetaPath(destPath), data, 0644)
+}
+
+// removePartialMeta 删除元数据
+func removePartialMeta(destPath string) {
+	os.Remove(partialMetaPath(destPath))
+}
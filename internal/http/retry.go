@@ -0,0 +1,182 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RetryPolicy 重试策略
+type RetryPolicy struct {
+	// 最大尝试次数（含首次请求），小于等于1表示不重试
+	MaxAttempts int
+	// 首次重试前的退避时长，之后按指数增长
+	BaseBackoff time.Duration
+	// 单次退避上限
+	MaxBackoff time.Duration
+	// 随机抖动比例（0~1）
+	Jitter float64
+	// 可重试的HTTP状态码
+	RetryableStatusCodes []int
+}
+
+// DefaultRetryPolicy 返回默认重试策略
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: 3,
+		BaseBackoff: 500 * time.Millisecond,
+		MaxBackoff:  30 * time.Second,
+		Jitter:      0.2,
+		RetryableStatusCodes: []int{
+			http.StatusRequestTimeout,
+			http.StatusTooManyRequests,
+			http.StatusInternalServerError,
+			http.StatusBadGateway,
+			http.StatusServiceUnavailable,
+			http.StatusGatewayTimeout,
+		},
+	}
+}
+
+// backoff 计算第attempt次失败后的退避时长
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	delay := float64(p.BaseBackoff) * math.Pow(2, float64(attempt-1))
+	if p.MaxBackoff > 0 && delay > float64(p.MaxBackoff) {
+		delay = float64(p.MaxBackoff)
+	}
+	if p.Jitter > 0 {
+		delay *= 1 + p.Jitter*(rand.Float64()*2-1)
+	}
+	return time.Duration(delay)
+}
+
+// retryableStatus 判断状态码是否可重试
+func (p RetryPolicy) retryableStatus(code int) bool {
+	for _, c := range p.RetryableStatusCodes {
+		if c == code {
+			return true
+		}
+	}
+	return false
+}
+
+// StatusError 非预期的HTTP状态码错误
+type StatusError struct {
+	StatusCode int
+	Body       string
+	// 服务端通过Retry-After要求的等待时长
+	RetryAfter time.Duration
+}
+
+// Error 实现error接口
+func (e *StatusError) Error() string {
+	if e.Body != "" {
+		return fmt.Sprintf("HTTP %d: %s", e.StatusCode, e.Body)
+	}
+	return fmt.Sprintf("HTTP %d", e.StatusCode)
+}
+
+// newStatusError 根据响应创建状态码错误
+func newStatusError(resp *http.Response, body string) *StatusError {
+	return &StatusError{
+		StatusCode: resp.StatusCode,
+		Body:       body,
+		RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After")),
+	}
+}
+
+// parseRetryAfter 解析Retry-After响应头（秒数或HTTP日期）
+func parseRetryAfter(value string) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
+
+// permanentError 不可重试的错误（如本地文件错误、响应解析失败）
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// permanent 标记错误不可重试
+func permanent(err error) error {
+	return &permanentError{err: err}
+}
+
+// withRetry 按重试策略执行fn，网络错误和可重试的状态码会在退避后重试，
+// 服务端返回Retry-After时以其为准（不超过MaxBackoff）；ctx取消后立即停止
+func (c *Client) withRetry(ctx context.Context, fn func() error) error {
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil {
+			return nil
+		}
+
+		if ctx.Err() != nil || attempt >= c.retry.MaxAttempts || !c.retryable(err) {
+			return unwrapPermanent(err)
+		}
+
+		wait := c.retry.backoff(attempt)
+		var statusErr *StatusError
+		if errors.As(err, &statusErr) && statusErr.RetryAfter > 0 {
+			wait = statusErr.RetryAfter
+			if c.retry.MaxBackoff > 0 && wait > c.retry.MaxBackoff {
+				wait = c.retry.MaxBackoff
+			}
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
+
+// retryable 判断错误是否可重试
+func (c *Client) retryable(err error) bool {
+	var permErr *permanentError
+	if errors.As(err, &permErr) {
+		return false
+	}
+
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return c.retry.retryableStatus(statusErr.StatusCode)
+	}
+
+	// 其余为网络或传输错误
+	return true
+}
+
+// unwrapPermanent 去掉不可重试标记，返回原始错误
+func unwrapPermanent(err error) error {
+	var permErr *permanentError
+	if errors.As(err, &permErr) {
+		return permErr.err
+	}
+	return err
+}
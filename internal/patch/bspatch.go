@@ -0,0 +1,100 @@
+package patch
+
+import (
+	"bytes"
+	"compress/bzip2"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// bsdiffMagic BSDIFF40格式文件头
+const bsdiffMagic = "BSDIFF40"
+
+// ErrCorruptPatch 补丁文件损坏
+var ErrCorruptPatch = errors.New("corrupt patch")
+
+// ErrTargetTooLarge 补丁声明的新文件大小超出限制
+var ErrTargetTooLarge = errors.New("patch target too large")
+
+// Apply 将bsdiff（BSDIFF40格式）补丁应用到old，返回新文件内容
+// maxSize为新文件大小上限，补丁文件头声明的大小超出时返回ErrTargetTooLarge，小于等于0时不限制
+//
+// 补丁格式：32字节文件头（魔数、控制块长度、差异块长度、新文件长度），
+// 随后依次为bzip2压缩的控制块、差异块和额外数据块
+func Apply(old []byte, patch []byte, maxSize int64) ([]byte, error) {
+	if len(patch) < 32 || string(patch[:8]) != bsdiffMagic {
+		return nil, fmt.Errorf("%w: invalid header", ErrCorruptPatch)
+	}
+
+	ctrlLen := offtin(patch[8:16])
+	diffLen := offtin(patch[16:24])
+	newSize := offtin(patch[24:32])
+	body := patch[32:]
+	// 分别与剩余长度比较，避免长度相加溢出
+	if ctrlLen < 0 || diffLen < 0 || newSize < 0 ||
+		ctrlLen > int64(len(body)) || diffLen > int64(len(body))-ctrlLen {
+		return nil, fmt.Errorf("%w: invalid block sizes", ErrCorruptPatch)
+	}
+	if maxSize > 0 && newSize > maxSize {
+		return nil, fmt.Errorf("%w: %d bytes exceeds limit of %d bytes", ErrTargetTooLarge, newSize, maxSize)
+	}
+
+	ctrl := bzip2.NewReader(bytes.NewReader(body[:ctrlLen]))
+	diff := bzip2.NewReader(bytes.NewReader(body[ctrlLen : ctrlLen+diffLen]))
+	extra := bzip2.NewReader(bytes.NewReader(body[ctrlLen+diffLen:]))
+
+	newData := make([]byte, newSize)
+	var oldPos, newPos int64
+	var buf [24]byte
+
+	for newPos < newSize {
+		// 读取控制三元组：差异长度、额外数据长度、旧文件偏移调整
+		if _, err := io.ReadFull(ctrl, buf[:]); err != nil {
+			return nil, fmt.Errorf("%w: failed to read control block: %v", ErrCorruptPatch, err)
+		}
+		addLen := offtin(buf[0:8])
+		copyLen := offtin(buf[8:16])
+		seek := offtin(buf[16:24])
+
+		// 与剩余长度比较，避免长度相加溢出
+		if addLen < 0 || copyLen < 0 || addLen > newSize-newPos {
+			return nil, fmt.Errorf("%w: invalid control data", ErrCorruptPatch)
+		}
+
+		// 差异数据与旧文件对应字节相加
+		if _, err := io.ReadFull(diff, newData[newPos:newPos+addLen]); err != nil {
+			return nil, fmt.Errorf("%w: failed to read diff block: %v", ErrCorruptPatch, err)
+		}
+		for i := int64(0); i < addLen; i++ {
+			if p := oldPos + i; p >= 0 && p < int64(len(old)) {
+				newData[newPos+i] += old[p]
+			}
+		}
+		newPos += addLen
+		oldPos += addLen
+
+		if copyLen > newSize-newPos {
+			return nil, fmt.Errorf("%w: invalid control data", ErrCorruptPatch)
+		}
+
+		// 额外数据直接写入
+		if _, err := io.ReadFull(extra, newData[newPos:newPos+copyLen]); err != nil {
+			return nil, fmt.Errorf("%w: failed to read extra block: %v", ErrCorruptPatch, err)
+		}
+		newPos += copyLen
+		oldPos += seek
+	}
+
+	return newData, nil
+}
+
+// offtin 解析bsdiff使用的符号-数值表示的64位小端整数
+func offtin(b []byte) int64 {
+	v := int64(binary.LittleEndian.Uint64(b) &^ (1 << 63))
+	if b[7]&0x80 != 0 {
+		return -v
+	}
+	return v
+}
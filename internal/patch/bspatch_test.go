@@ -0,0 +1,87 @@
+package patch
+
+import (
+	"encoding/binary"
+	"errors"
+	"os"
+	"testing"
+)
+
+// testPatchPath 将"echo version 1.0.0"脚本升级为1.1.0并追加一行的BSDIFF40补丁（pkg/client的测试同样使用）
+const testPatchPath = "testdata/upgrade.bsdiff"
+
+func readTestPatch(t *testing.T) []byte {
+	t.Helper()
+	return readPatchFile(t, testPatchPath)
+}
+
+func readPatchFile(t *testing.T, path string) []byte {
+	t.Helper()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+// overflowHeader 返回控制块和差异块长度之和溢出int64的补丁
+func overflowHeader(data []byte) []byte {
+	patch := append([]byte(nil), data...)
+	binary.LittleEndian.PutUint64(patch[8:16], 16)
+	binary.LittleEndian.PutUint64(patch[16:24], 1<<63-1)
+	return patch
+}
+
+func TestApply(t *testing.T) {
+	data := readTestPatch(t)
+
+	result, err := Apply([]byte("#!/bin/sh\necho version 1.0.0\n"), data, 0)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	expected := "#!/bin/sh\necho version 1.1.0\nexit 0\n"
+	if string(result) != expected {
+		t.Errorf("Expected %q, got %q", expected, string(result))
+	}
+}
+
+func TestApplyCorruptPatch(t *testing.T) {
+	data := readTestPatch(t)
+
+	testCases := []struct {
+		name  string
+		patch []byte
+	}{
+		{name: "bad magic", patch: append([]byte("BSDIFF41"), data[8:]...)},
+		{name: "truncated header", patch: data[:16]},
+		{name: "truncated body", patch: data[:len(data)-20]},
+		{name: "overflowing block sizes", patch: overflowHeader(data)},
+		// 第二个控制三元组的差异长度/额外数据长度为1<<63-1，与已写入位置相加溢出
+		{name: "overflowing diff length", patch: readPatchFile(t, "testdata/overflow-add.bsdiff")},
+		{name: "overflowing extra length", patch: readPatchFile(t, "testdata/overflow-copy.bsdiff")},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := Apply([]byte("#!/bin/sh\necho version 1.0.0\n"), tc.patch, 0); !errors.Is(err, ErrCorruptPatch) {
+				t.Errorf("Expected ErrCorruptPatch, got %v", err)
+			}
+		})
+	}
+}
+
+func TestApplyTargetTooLarge(t *testing.T) {
+	data := readTestPatch(t)
+	patch := append([]byte(nil), data...)
+	binary.LittleEndian.PutUint64(patch[24:32], 1<<62)
+
+	if _, err := Apply([]byte("#!/bin/sh\necho version 1.0.0\n"), patch, 1<<20); !errors.Is(err, ErrTargetTooLarge) {
+		t.Errorf("Expected ErrTargetTooLarge, got %v", err)
+	}
+	// 新文件36字节，限制为35字节时拒绝
+	if _, err := Apply([]byte("#!/bin/sh\necho version 1.0.0\n"), data, 35); !errors.Is(err, ErrTargetTooLarge) {
+		t.Errorf("Expected ErrTargetTooLarge, got %v", err)
+	}
+}
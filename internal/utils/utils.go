@@ -1,8 +1,6 @@
 package utils
 
 import (
-	"crypto/md5"
-	"fmt"
 	"io"
 	"os"
 	"path/filepath"
@@ -25,7 +23,7 @@ func FileExists(path string) bool {
 	return !os.IsNotExist(err)
 }
 
-// CopyFile 复制文件
+// CopyFile 复制文件，内容写入后同步到磁盘
 func CopyFile(src, dst string) error {
 	// 确保目标目录存在
 	if err := EnsureDir(filepath.Dir(dst)); err != nil {
@@ -50,6 +48,9 @@ func CopyFile(src, dst string) error {
 	if _, err := io.Copy(dstFile, srcFile); err != nil {
 		return err
 	}
+	if err := dstFile.Sync(); err != nil {
+		return err
+	}
 
 	// 复制权限
 	srcInfo, err := srcFile.Stat()
@@ -60,25 +61,82 @@ func CopyFile(src, dst string) error {
 	return os.Chmod(dst, srcInfo.Mode())
 }
 
-// VerifyFileMD5 验证文件MD5
-func VerifyFileMD5(filePath, expectedMD5 string) error {
-	file, err := os.Open(filePath)
+// CopySymlink 复制符号链接本身（不跟随链接），目标位置已存在的文件或链接会被替换
+func CopySymlink(src, dst string) error {
+	link, err := os.Readlink(src)
+	if err != nil {
+		return err
+	}
+
+	if err := EnsureDir(filepath.Dir(dst)); err != nil {
+		return err
+	}
+
+	if info, err := os.Lstat(dst); err == nil && !info.IsDir() {
+		if err := os.Remove(dst); err != nil {
+			return err
+		}
+	}
+
+	return os.Symlink(link, dst)
+}
+
+// WriteFileAtomic 原子写入文件
+// 先写入同目录下的临时文件并同步到磁盘，再通过rename替换目标文件，
+// 保证目标文件要么是旧内容，要么是完整的新内容
+func WriteFileAtomic(path string, data []byte, perm os.FileMode) error {
+	dir := filepath.Dir(path)
+	if err := EnsureDir(dir); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".tmp-*")
 	if err != nil {
 		return err
 	}
-	defer file.Close()
+	tmpPath := tmp.Name()
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := os.Chmod(tmpPath, perm); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
 
-	hash := md5.New()
-	if _, err := io.Copy(hash, file); err != nil {
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
 		return err
 	}
 
-	actualMD5 := fmt.Sprintf("%x", hash.Sum(nil))
-	if actualMD5 != expectedMD5 {
-		return fmt.Errorf("MD5 mismatch: expected %s, got %s", expectedMD5, actualMD5)
+	return SyncDir(dir)
+}
+
+// SyncDir 同步目录元数据，确保rename等操作已持久化
+func SyncDir(dir string) error {
+	if runtime.GOOS == "windows" {
+		// Windows不支持对目录执行fsync
+		return nil
+	}
+
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
 	}
+	defer d.Close()
 
-	return nil
+	return d.Sync()
 }
 
 // CreateTempDir 创建临时目录
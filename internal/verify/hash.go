@@ -0,0 +1,160 @@
+package verify
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// 内置的哈希算法
+const (
+	HashMD5    = "md5"
+	HashSHA256 = "sha256"
+	HashSHA512 = "sha512"
+)
+
+// hashAlgorithm 哈希算法定义
+type hashAlgorithm struct {
+	name     string
+	factory  func() hash.Hash
+	size     int
+	strength int
+}
+
+var (
+	hashMu     sync.RWMutex
+	hashAlgos  = map[string]*hashAlgorithm{}
+	weakHashes = map[string]bool{HashMD5: true}
+)
+
+func init() {
+	RegisterHash(HashMD5, 1, md5.New)
+	RegisterHash(HashSHA256, 2, sha256.New)
+	RegisterHash(HashSHA512, 3, sha512.New)
+}
+
+// RegisterHash 注册哈希算法，strength越大优先级越高（用于与服务端协商算法）
+// 已存在同名算法时覆盖
+func RegisterHash(name string, strength int, factory func() hash.Hash) {
+	name = strings.ToLower(name)
+
+	hashMu.Lock()
+	defer hashMu.Unlock()
+
+	hashAlgos[name] = &hashAlgorithm{
+		name:     name,
+		factory:  factory,
+		size:     factory().Size(),
+		strength: strength,
+	}
+}
+
+// SupportedHashes 返回支持的哈希算法名称，按优先级从高到低排序
+func SupportedHashes(includeWeak bool) []string {
+	hashMu.RLock()
+	defer hashMu.RUnlock()
+
+	names := make([]string, 0, len(hashAlgos))
+	for name := range hashAlgos {
+		if !includeWeak && weakHashes[name] {
+			continue
+		}
+		names = append(names, name)
+	}
+
+	// 按强度降序，强度相同按名称排序
+	sort.Slice(names, func(i, j int) bool {
+		return lessHash(hashAlgos[names[j]], hashAlgos[names[i]])
+	})
+
+	return names
+}
+
+// lessHash 判断a的优先级是否低于b
+func lessHash(a, b *hashAlgorithm) bool {
+	if a.strength != b.strength {
+		return a.strength < b.strength
+	}
+	return a.name > b.name
+}
+
+// IsWeakHash 判断算法是否为弱哈希（如MD5）
+func IsWeakHash(algorithm string) bool {
+	return weakHashes[strings.ToLower(algorithm)]
+}
+
+// ParseHash 解析哈希值，支持"sha256:abcd..."形式的算法前缀；
+// 无前缀时按摘要长度推断算法（32位十六进制为MD5，64位为SHA-256，128位为SHA-512）
+func ParseHash(s string) (algorithm, digest string, err error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return "", "", fmt.Errorf("empty hash")
+	}
+
+	hashMu.RLock()
+	defer hashMu.RUnlock()
+
+	if i := strings.IndexAny(s, ":="); i > 0 {
+		algorithm = strings.ToLower(strings.ReplaceAll(s[:i], "-", ""))
+		digest = strings.ToLower(s[i+1:])
+		algo, ok := hashAlgos[algorithm]
+		if !ok {
+			return "", "", fmt.Errorf("unsupported hash algorithm: %s", s[:i])
+		}
+		if len(digest) != algo.size*2 {
+			return "", "", fmt.Errorf("invalid %s digest length: %d", algorithm, len(digest))
+		}
+		return algorithm, digest, nil
+	}
+
+	digest = strings.ToLower(s)
+	var best *hashAlgorithm
+	for _, algo := range hashAlgos {
+		if len(digest) == algo.size*2 && (best == nil || lessHash(best, algo)) {
+			best = algo
+		}
+	}
+	if best == nil {
+		return "", "", fmt.Errorf("cannot infer hash algorithm from digest length %d", len(digest))
+	}
+
+	return best.name, digest, nil
+}
+
+// VerifyFileHash 校验文件哈希，expected的格式见ParseHash
+func VerifyFileHash(path, expected string) error {
+	algorithm, digest, err := ParseHash(expected)
+	if err != nil {
+		return err
+	}
+
+	hashMu.RLock()
+	factory := hashAlgos[algorithm].factory
+	hashMu.RUnlock()
+
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	h := factory()
+	if _, err := io.Copy(h, file); err != nil {
+		return err
+	}
+
+	actual := hex.EncodeToString(h.Sum(nil))
+	if actual != digest {
+		return fmt.Errorf("%s mismatch: expected %s, got %s", algorithm, digest, actual)
+	}
+
+	return nil
+}
@@ -0,0 +1,159 @@
+package verify
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"strings"
+)
+
+// 支持的签名算法
+const (
+	AlgorithmEd25519   = "ed25519"
+	AlgorithmECDSAP256 = "ecdsa-p256-sha256"
+)
+
+// ParsePublicKey 解析公钥
+// 支持PEM编码的PKIX公钥（Ed25519或ECDSA P-256），以及base64编码的32字节Ed25519原始公钥
+func ParsePublicKey(s string) (crypto.PublicKey, error) {
+	s = strings.TrimSpace(s)
+
+	if block, _ := pem.Decode([]byte(s)); block != nil {
+		key, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse PEM public key: %w", err)
+		}
+
+		switch k := key.(type) {
+		case ed25519.PublicKey:
+			return k, nil
+		case *ecdsa.PublicKey:
+			if k.Curve != elliptic.P256() {
+				return nil, fmt.Errorf("unsupported ECDSA curve: %s", k.Curve.Params().Name)
+			}
+			return k, nil
+		default:
+			return nil, fmt.Errorf("unsupported public key type: %T", key)
+		}
+	}
+
+	raw, err := decodeBase64(s)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode public key: %w", err)
+	}
+	if len(raw) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("invalid Ed25519 public key size: %d", len(raw))
+	}
+
+	return ed25519.PublicKey(raw), nil
+}
+
+// NormalizeAlgorithm 规范化签名算法名称，空字符串表示按公钥类型自动匹配
+func NormalizeAlgorithm(algorithm string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(algorithm)) {
+	case "":
+		return "", nil
+	case "ed25519", "eddsa":
+		return AlgorithmEd25519, nil
+	case "ecdsa-p256-sha256", "ecdsa-p256", "ecdsa", "es256", "p256":
+		return AlgorithmECDSAP256, nil
+	default:
+		return "", fmt.Errorf("unsupported signature algorithm: %s", algorithm)
+	}
+}
+
+// VerifyFileSignature 校验文件签名
+// 签名对象为文件内容的SHA-256摘要，签名使用base64编码；
+// 只要任一受信任公钥校验通过即视为有效，便于密钥轮换期间同时信任新旧密钥
+func VerifyFileSignature(path, signature, algorithm string, keys []crypto.PublicKey) error {
+	if len(keys) == 0 {
+		return fmt.Errorf("no trusted public keys")
+	}
+
+	algorithm, err := NormalizeAlgorithm(algorithm)
+	if err != nil {
+		return err
+	}
+
+	sig, err := decodeBase64(strings.TrimSpace(signature))
+	if err != nil {
+		return fmt.Errorf("failed to decode signature: %w", err)
+	}
+
+	digest, err := fileSHA256(path)
+	if err != nil {
+		return err
+	}
+
+	for _, key := range keys {
+		if verifyDigest(key, algorithm, digest, sig) {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("signature does not match any trusted public key")
+}
+
+// verifyDigest 使用单个公钥校验摘要签名
+func verifyDigest(key crypto.PublicKey, algorithm string, digest, sig []byte) bool {
+	switch k := key.(type) {
+	case ed25519.PublicKey:
+		if algorithm != "" && algorithm != AlgorithmEd25519 {
+			return false
+		}
+		return ed25519.Verify(k, digest, sig)
+
+	case *ecdsa.PublicKey:
+		if algorithm != "" && algorithm != AlgorithmECDSAP256 {
+			return false
+		}
+		if ecdsa.VerifyASN1(k, digest, sig) {
+			return true
+		}
+		// 兼容r||s拼接的原始签名格式
+		if len(sig) == 64 {
+			r := new(big.Int).SetBytes(sig[:32])
+			s := new(big.Int).SetBytes(sig[32:])
+			return ecdsa.Verify(k, digest, r, s)
+		}
+		return false
+
+	default:
+		return false
+	}
+}
+
+// fileSHA256 计算文件的SHA-256摘要
+func fileSHA256(path string) ([]byte, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return nil, err
+	}
+
+	return hash.Sum(nil), nil
+}
+
+// decodeBase64 解码标准或URL安全的base64（允许省略填充）
+func decodeBase64(s string) ([]byte, error) {
+	for _, enc := range []*base64.Encoding{base64.StdEncoding, base64.RawStdEncoding, base64.URLEncoding, base64.RawURLEncoding} {
+		if data, err := enc.DecodeString(s); err == nil {
+			return data, nil
+		}
+	}
+	return nil, fmt.Errorf("invalid base64 string")
+}
@@ -0,0 +1,139 @@
+package verify
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeTestFile(t *testing.T, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "artifact.bin")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestVerifyFileSignatureEd25519(t *testing.T) {
+	path := writeTestFile(t, "release payload")
+	digest := sha256.Sum256([]byte("release payload"))
+
+	oldPub, _, _ := ed25519.GenerateKey(rand.Reader)
+	newPub, newPriv, _ := ed25519.GenerateKey(rand.Reader)
+	signature := base64.StdEncoding.EncodeToString(ed25519.Sign(newPriv, digest[:]))
+
+	// 原始base64公钥与PEM公钥都可解析
+	rawKey, err := ParsePublicKey(base64.StdEncoding.EncodeToString(oldPub))
+	if err != nil {
+		t.Fatalf("Expected raw key to parse, got %v", err)
+	}
+	der, _ := x509.MarshalPKIXPublicKey(newPub)
+	pemKey, err := ParsePublicKey(string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})))
+	if err != nil {
+		t.Fatalf("Expected PEM key to parse, got %v", err)
+	}
+
+	// 密钥轮换：任一受信任公钥通过即可
+	if err := VerifyFileSignature(path, signature, "ed25519", []crypto.PublicKey{rawKey, pemKey}); err != nil {
+		t.Errorf("Expected signature to verify, got %v", err)
+	}
+
+	if err := VerifyFileSignature(path, signature, "ed25519", []crypto.PublicKey{rawKey}); err == nil {
+		t.Error("Expected signature from untrusted key to fail")
+	}
+
+	tampered := writeTestFile(t, "release payl0ad")
+	if err := VerifyFileSignature(tampered, signature, "", []crypto.PublicKey{pemKey}); err == nil {
+		t.Error("Expected tampered file to fail verification")
+	}
+}
+
+func TestVerifyFileSignatureECDSA(t *testing.T) {
+	path := writeTestFile(t, "release payload")
+	digest := sha256.Sum256([]byte("release payload"))
+
+	priv, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	sig, _ := ecdsa.SignASN1(rand.Reader, priv, digest[:])
+
+	der, _ := x509.MarshalPKIXPublicKey(&priv.PublicKey)
+	key, err := ParsePublicKey(string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})))
+	if err != nil {
+		t.Fatalf("Expected PEM key to parse, got %v", err)
+	}
+
+	signature := base64.StdEncoding.EncodeToString(sig)
+	if err := VerifyFileSignature(path, signature, "ecdsa-p256-sha256", []crypto.PublicKey{key}); err != nil {
+		t.Errorf("Expected signature to verify, got %v", err)
+	}
+
+	if err := VerifyFileSignature(path, signature, "ed25519", []crypto.PublicKey{key}); err == nil {
+		t.Error("Expected algorithm mismatch to fail verification")
+	}
+}
+
+func TestParseHash(t *testing.T) {
+	sha256Digest := "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
+
+	testCases := []struct {
+		input     string
+		algorithm string
+		wantErr   bool
+	}{
+		{input: "098f6bcd4621d373cade4e832627b4f6", algorithm: HashMD5},
+		{input: sha256Digest, algorithm: HashSHA256},
+		{input: "sha256:" + sha256Digest, algorithm: HashSHA256},
+		{input: "SHA-256:" + sha256Digest, algorithm: HashSHA256},
+		{input: "sha512:" + sha256Digest, wantErr: true},
+		{input: "crc32:deadbeef", wantErr: true},
+		{input: "abc", wantErr: true},
+		{input: "", wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		algorithm, _, err := ParseHash(tc.input)
+		if tc.wantErr {
+			if err == nil {
+				t.Errorf("ParseHash(%q): expected error", tc.input)
+			}
+			continue
+		}
+		if err != nil || algorithm != tc.algorithm {
+			t.Errorf("ParseHash(%q) = %q, %v; want %q", tc.input, algorithm, err, tc.algorithm)
+		}
+	}
+}
+
+func TestVerifyFileHash(t *testing.T) {
+	path := writeTestFile(t, "test")
+
+	valid := []string{
+		"098f6bcd4621d373cade4e832627b4f6",
+		"sha256:9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08",
+		"sha512:ee26b0dd4af7e749aa1a8ee3c10ae9923f618980772e473f8819a5d4940e0db27ac185f8a0e1d5f84f88bc887fd67b143732c304cc5fa9ad8e6f57f50028a8ff",
+	}
+	for _, expected := range valid {
+		if err := VerifyFileHash(path, expected); err != nil {
+			t.Errorf("VerifyFileHash(%q): expected no error, got %v", expected, err)
+		}
+	}
+
+	if err := VerifyFileHash(path, "sha256:"+strings.Repeat("0", 64)); err == nil {
+		t.Error("Expected hash mismatch error")
+	}
+
+	if got := SupportedHashes(false); len(got) != 2 || got[0] != HashSHA512 || got[1] != HashSHA256 {
+		t.Errorf("Expected strong hashes ordered by strength, got %v", got)
+	}
+}
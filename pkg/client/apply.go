@@ -0,0 +1,476 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/CooperJiang/versiontrack-go-sdk/internal/utils"
+)
+
+const (
+	// applyJournalName 应用更新的预写日志文件名称（位于状态目录下）
+	applyJournalName = "apply.journal"
+	// stagedSuffix 暂存新文件的后缀，与目标文件位于同一目录以保证rename不跨文件系统
+	stagedSuffix = ".vt-new"
+	// replacedSuffix 被替换的旧文件的后缀，提交后删除
+	replacedSuffix = ".vt-old"
+)
+
+// 应用更新的日志状态
+const (
+	applyStateStaging     = "staging"      // 正在暂存新文件，安装目录未被修改，恢复时回滚
+	applyStateSwapping    = "swapping"     // 新文件已全部暂存，正在替换，恢复时继续完成
+	applyStateRollingBack = "rolling_back" // 替换失败，正在回滚，恢复时继续回滚
+	applyStateCommitted   = "committed"    // 替换完成，等待记录更新历史和清理旧文件
+)
+
+// applyJournal 应用更新的预写日志
+type applyJournal struct {
+	// 当前状态
+	State string `json:"state"`
+	// 提交后需要写入的更新记录
+	Record *UpdateRecord `json:"record,omitempty"`
+	// 需要替换的文件（相对安装目录）
+	Entries []applyEntry `json:"entries"`
+	// 暂存时新建的目录（相对安装目录），回滚时删除
+	Dirs []string `json:"dirs,omitempty"`
+	// 新版本的文件清单，为nil表示未知
+	Manifest []string `json:"manifest,omitempty"`
+	// 替换阶段已修改的条目数（含替换到一半的条目），回滚时只恢复前Swapped个条目
+	Swapped int `json:"swapped,omitempty"`
+}
+
+// applyEntry 一个需要替换的文件
+type applyEntry struct {
+	// 相对安装目录的路径
+	Path string `json:"path"`
+	// 替换前目标位置是否已有文件
+	Existed bool `json:"existed"`
+	// 是否为新版本中已移除、需要删除的文件
+	Remove bool `json:"remove,omitempty"`
+}
+
+// applyJournalPath 返回应用日志路径
+func (c *Client) applyJournalPath() string {
+	return filepath.Join(c.stateDir(), applyJournalName)
+}
+
+// applyUpdate 以事务方式将updateDir中的文件应用到安装目录
+// 先将新文件暂存到目标文件旁并同步到磁盘，再逐个通过rename替换，旧文件改名保留到提交为止；
+// 每个阶段开始前写入日志，进程中断后由recoverApply继续完成或回滚。
+// 成功返回时日志处于committed状态，调用方记录更新历史后调用commitApply完成清理。
+// partial为false时，旧版本文件清单中有而updateDir中没有的文件会被删除
+func (c *Client) applyUpdate(updateDir string, record UpdateRecord, partial bool) (*applyJournal, error) {
+	journal := &applyJournal{State: applyStateStaging, Record: &record}
+	var files []string
+
+	// 收集需要替换的文件
+	err := filepath.Walk(updateDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if info.IsDir() {
+			return nil
+		}
+
+		// 计算相对路径
+		relPath, err := filepath.Rel(updateDir, path)
+		if err != nil {
+			return err
+		}
+
+		targetPath := filepath.Join(c.installDir, relPath)
+		files = append(files, filepath.ToSlash(relPath))
+
+		// 检查是否是需要保护的文件
+		if c.shouldPreserveFile(relPath) {
+			// 如果目标文件已存在，跳过覆盖
+			if utils.FileExists(targetPath) {
+				return nil
+			}
+		}
+
+		existed := false
+		if targetInfo, err := os.Lstat(targetPath); err == nil {
+			if targetInfo.IsDir() {
+				return fmt.Errorf("cannot replace directory with file: %s", relPath)
+			}
+			existed = true
+		}
+
+		journal.Entries = append(journal.Entries, applyEntry{Path: relPath, Existed: existed})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// 根据旧版本文件清单生成新版本清单和需要删除的文件
+	previous := c.manifest(record.FromVersion)
+	if partial {
+		if previous != nil {
+			journal.Manifest = mergeManifest(previous, files)
+		}
+	} else {
+		journal.Manifest = files
+		journal.Entries = append(journal.Entries, c.removedEntries(previous, files)...)
+	}
+
+	// 记录暂存时需要新建的目录
+	journal.Dirs = c.missingDirs(journal.Entries)
+	if err := c.writeApplyJournal(journal); err != nil {
+		return nil, err
+	}
+
+	// 1. 暂存新文件
+	if err := c.stageFiles(updateDir, journal.Entries); err != nil {
+		return nil, c.abortApply(journal, err)
+	}
+
+	// 2. 替换文件
+	journal.State = applyStateSwapping
+	if err := c.writeApplyJournal(journal); err != nil {
+		return nil, c.abortApply(journal, err)
+	}
+	journal.Swapped, err = c.swapFiles(journal.Entries)
+	if err == nil {
+		// 3. 标记为已提交，此后中断将保留新版本
+		journal.State = applyStateCommitted
+		err = c.writeApplyJournal(journal)
+	}
+	if err != nil {
+		journal.State = applyStateRollingBack
+		c.writeApplyJournal(journal)
+		return nil, c.abortApply(journal, err)
+	}
+
+	return journal, nil
+}
+
+// removedEntries 返回旧版本清单中有、新版本中没有且仍存在的文件
+// 受保护的文件、状态目录和不在清单中的用户文件不会被删除
+func (c *Client) removedEntries(previous, files []string) []applyEntry {
+	current := make(map[string]bool, len(files))
+	for _, file := range files {
+		current[file] = true
+	}
+
+	var entries []applyEntry
+	for _, file := range previous {
+		relPath := filepath.FromSlash(file)
+		if current[file] || !filepath.IsLocal(relPath) || c.shouldPreserveFile(relPath) {
+			continue
+		}
+		if strings.SplitN(file, "/", 2)[0] == stateDirName {
+			continue
+		}
+
+		info, err := os.Lstat(filepath.Join(c.installDir, relPath))
+		if err != nil || info.IsDir() {
+			continue
+		}
+		entries = append(entries, applyEntry{Path: relPath, Existed: true, Remove: true})
+	}
+	return entries
+}
+
+// mergeManifest 合并两个文件清单并排序
+func mergeManifest(previous, files []string) []string {
+	seen := make(map[string]bool, len(previous)+len(files))
+	var merged []string
+	for _, file := range append(append([]string{}, previous...), files...) {
+		if !seen[file] {
+			seen[file] = true
+			merged = append(merged, file)
+		}
+	}
+	sort.Strings(merged)
+	return merged
+}
+
+// swapFiles 依次替换所有文件并同步目录，返回已修改的条目数（失败时包含替换到一半的条目）
+func (c *Client) swapFiles(entries []applyEntry) (int, error) {
+	for i, entry := range entries {
+		if err := c.swapFile(entry); err != nil {
+			return i + 1, err
+		}
+	}
+	return len(entries), c.syncDirs(entries)
+}
+
+// stageFiles 将新文件复制到目标文件旁的暂存路径并同步到磁盘
+// 同时删除之前的更新未能清理的旧文件（如Windows下仍在运行的程序），保证替换阶段产生的旧文件都属于本次更新
+func (c *Client) stageFiles(updateDir string, entries []applyEntry) error {
+	for _, entry := range entries {
+		target := filepath.Join(c.installDir, entry.Path)
+		if entry.Existed {
+			if err := removeIfExists(target + replacedSuffix); err != nil {
+				return fmt.Errorf("failed to remove stale %s: %w", entry.Path+replacedSuffix, err)
+			}
+		}
+		if entry.Remove {
+			continue
+		}
+		src := filepath.Join(updateDir, entry.Path)
+		staged := target + stagedSuffix
+
+		info, err := os.Lstat(src)
+		if err != nil {
+			return err
+		}
+
+		// 符号链接按原链接目标重新创建
+		if info.Mode()&os.ModeSymlink != 0 {
+			err = utils.CopySymlink(src, staged)
+		} else {
+			err = utils.CopyFile(src, staged)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to stage %s: %w", entry.Path, err)
+		}
+	}
+
+	return c.syncDirs(entries)
+}
+
+// swapFile 将目标文件改名为旧文件，再将暂存文件改名为目标文件（需要删除的文件只改名为旧文件）
+func (c *Client) swapFile(entry applyEntry) error {
+	target := filepath.Join(c.installDir, entry.Path)
+
+	if entry.Existed {
+		if err := os.Rename(target, target+replacedSuffix); err != nil {
+			return fmt.Errorf("failed to replace %s: %w", entry.Path, err)
+		}
+	}
+	if entry.Remove {
+		return nil
+	}
+	if err := os.Rename(target+stagedSuffix, target); err != nil {
+		return fmt.Errorf("failed to replace %s: %w", entry.Path, err)
+	}
+	return nil
+}
+
+// abortApply 回滚本次应用并删除日志，返回原始错误
+func (c *Client) abortApply(journal *applyJournal, cause error) error {
+	if err := c.rollbackApply(journal); err != nil {
+		return fmt.Errorf("%w (rollback failed: %v)", cause, err)
+	}
+	os.Remove(c.applyJournalPath())
+	return cause
+}
+
+// rollbackApply 恢复被替换的旧文件，删除新增文件、暂存文件和新建目录
+// 可重复执行，用于处理回滚过程中再次中断的情况
+func (c *Client) rollbackApply(journal *applyJournal) error {
+	for i := len(journal.Entries) - 1; i >= 0; i-- {
+		entry := journal.Entries[i]
+		target := filepath.Join(c.installDir, entry.Path)
+
+		// 暂存阶段未修改任何目标文件，替换阶段只恢复已修改的条目，
+		// 避免把不属于本次更新的旧文件改名回原位置
+		if journal.State != applyStateStaging && i < journal.Swapped {
+			if err := c.revertFile(entry); err != nil {
+				return err
+			}
+		}
+
+		if err := removeIfExists(target + stagedSuffix); err != nil {
+			return fmt.Errorf("failed to remove staged file: %w", err)
+		}
+	}
+
+	// 从深到浅删除新建的目录，目录非空时保留
+	for i := len(journal.Dirs) - 1; i >= 0; i-- {
+		os.Remove(filepath.Join(c.installDir, journal.Dirs[i]))
+	}
+
+	return c.syncDirs(journal.Entries)
+}
+
+// revertFile 撤销单个文件的替换：旧文件存在时改名回原位置（覆盖新文件），原先不存在的文件直接删除
+func (c *Client) revertFile(entry applyEntry) error {
+	target := filepath.Join(c.installDir, entry.Path)
+
+	if !entry.Existed {
+		if err := removeIfExists(target); err != nil {
+			return fmt.Errorf("failed to remove %s: %w", entry.Path, err)
+		}
+		return nil
+	}
+
+	if _, err := os.Lstat(target + replacedSuffix); err != nil {
+		return nil
+	}
+	if err := os.Rename(target+replacedSuffix, target); err != nil {
+		return fmt.Errorf("failed to restore %s: %w", entry.Path, err)
+	}
+	return nil
+}
+
+// rollForwardApply 完成中断的替换：仍存在暂存文件的条目继续替换，仍存在的待删除文件改名为旧文件
+func (c *Client) rollForwardApply(journal *applyJournal) error {
+	for _, entry := range journal.Entries {
+		target := filepath.Join(c.installDir, entry.Path)
+		if entry.Remove {
+			if _, err := os.Lstat(target); err != nil {
+				continue
+			}
+			if err := c.swapFile(entry); err != nil {
+				return err
+			}
+			continue
+		}
+		if _, err := os.Lstat(target + stagedSuffix); err != nil {
+			continue
+		}
+
+		// 目标已被改名为旧文件时只需移入暂存文件
+		if entry.Existed {
+			if _, err := os.Lstat(target + replacedSuffix); err == nil {
+				if _, err := os.Lstat(target); os.IsNotExist(err) {
+					entry.Existed = false
+				}
+			}
+		}
+		if err := c.swapFile(entry); err != nil {
+			return err
+		}
+	}
+
+	return c.syncDirs(journal.Entries)
+}
+
+// commitApply 删除被替换的旧文件和日志，以及删除文件后留下的空目录
+// 旧文件删除失败（如Windows下仍在运行的程序）时保留，下次更新替换前会再次尝试删除
+func (c *Client) commitApply(journal *applyJournal) {
+	for _, entry := range journal.Entries {
+		if !entry.Existed {
+			continue
+		}
+		os.Remove(filepath.Join(c.installDir, entry.Path) + replacedSuffix)
+
+		if entry.Remove {
+			for dir := filepath.Dir(entry.Path); dir != "."; dir = filepath.Dir(dir) {
+				if os.Remove(filepath.Join(c.installDir, dir)) != nil {
+					break
+				}
+			}
+		}
+	}
+	os.Remove(c.applyJournalPath())
+}
+
+// recoverApply 检查上次应用更新是否中断，根据日志状态继续完成或回滚
+func (c *Client) recoverApply() error {
+	journal, err := c.readApplyJournal()
+	if err != nil || journal == nil {
+		return err
+	}
+
+	switch journal.State {
+	case applyStateStaging, applyStateRollingBack:
+		if err := c.rollbackApply(journal); err != nil {
+			return err
+		}
+		return os.Remove(c.applyJournalPath())
+	case applyStateSwapping:
+		if err := c.rollForwardApply(journal); err != nil {
+			return err
+		}
+	case applyStateCommitted:
+	default:
+		return fmt.Errorf("unknown apply journal state: %s", journal.State)
+	}
+
+	// 新版本已就位，补写中断前未能保存的更新记录
+	if record := journal.Record; record != nil && c.GetInstalledVersion() != record.Version {
+		if err := c.recordUpdate(*record, journal.Manifest); err != nil {
+			return err
+		}
+	}
+	c.commitApply(journal)
+	return nil
+}
+
+// removeIfExists 删除文件或符号链接，路径不存在（包括父路径不是目录）时忽略
+func removeIfExists(path string) error {
+	if _, err := os.Lstat(path); err != nil {
+		return nil
+	}
+	return os.Remove(path)
+}
+
+// readApplyJournal 读取应用日志，不存在时返回nil
+func (c *Client) readApplyJournal() (*applyJournal, error) {
+	data, err := os.ReadFile(c.applyJournalPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read apply journal: %w", err)
+	}
+
+	var journal applyJournal
+	if err := json.Unmarshal(data, &journal); err != nil {
+		return nil, fmt.Errorf("failed to decode apply journal: %w", err)
+	}
+	return &journal, nil
+}
+
+// writeApplyJournal 原子写入应用日志
+func (c *Client) writeApplyJournal(journal *applyJournal) error {
+	data, err := json.MarshalIndent(journal, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode apply journal: %w", err)
+	}
+
+	if err := utils.WriteFileAtomic(c.applyJournalPath(), data, 0644); err != nil {
+		return fmt.Errorf("failed to write apply journal: %w", err)
+	}
+	return nil
+}
+
+// missingDirs 返回暂存文件时需要新建的目录，按从浅到深排序
+func (c *Client) missingDirs(entries []applyEntry) []string {
+	seen := make(map[string]bool)
+	var dirs []string
+	for _, entry := range entries {
+		if entry.Remove {
+			continue
+		}
+		for dir := filepath.Dir(entry.Path); dir != "." && !seen[dir]; dir = filepath.Dir(dir) {
+			seen[dir] = true
+			if _, err := os.Lstat(filepath.Join(c.installDir, dir)); os.IsNotExist(err) {
+				dirs = append(dirs, dir)
+			}
+		}
+	}
+
+	sort.Slice(dirs, func(i, j int) bool {
+		return len(dirs[i]) < len(dirs[j])
+	})
+	return dirs
+}
+
+// syncDirs 同步所有条目所在目录的元数据，确保rename已持久化
+func (c *Client) syncDirs(entries []applyEntry) error {
+	seen := make(map[string]bool)
+	for _, entry := range entries {
+		dir := filepath.Dir(filepath.Join(c.installDir, entry.Path))
+		if seen[dir] {
+			continue
+		}
+		seen[dir] = true
+
+		if err := utils.SyncDir(dir); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
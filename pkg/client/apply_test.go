@@ -0,0 +1,316 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/CooperJiang/versiontrack-go-sdk/internal/utils"
+)
+
+// selfUpdateDirEnv 子进程中运行的辅助测试更新的安装目录，未设置时辅助测试跳过
+const selfUpdateDirEnv = "VT_SELF_UPDATE_DIR"
+
+func writeTestFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func assertFileContent(t *testing.T, path, expected string) {
+	t.Helper()
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Expected %s to exist, got %v", path, err)
+	}
+	if string(content) != expected {
+		t.Errorf("Expected %s to contain %q, got %q", path, expected, content)
+	}
+}
+
+func assertNoApplyLeftovers(t *testing.T, installDir string) {
+	t.Helper()
+	filepath.Walk(installDir, func(path string, info os.FileInfo, err error) error {
+		if err == nil && (filepath.Ext(path) == stagedSuffix || filepath.Ext(path) == replacedSuffix) {
+			t.Errorf("Expected no leftover file, found %s", path)
+		}
+		return nil
+	})
+	if _, err := os.Stat(filepath.Join(installDir, stateDirName, applyJournalName)); !os.IsNotExist(err) {
+		t.Errorf("Expected apply journal to be removed, got %v", err)
+	}
+}
+
+func writeApplyJournalFile(t *testing.T, installDir string, journal applyJournal) {
+	t.Helper()
+	data, err := json.Marshal(journal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeTestFile(t, filepath.Join(installDir, stateDirName, applyJournalName), string(data))
+}
+
+func TestApplyUpdateSwapsFiles(t *testing.T) {
+	installDir := t.TempDir()
+	writeTestFile(t, filepath.Join(installDir, "app"), "old binary")
+	writeTestFile(t, filepath.Join(installDir, "config.yaml"), "user config")
+
+	updateDir := t.TempDir()
+	writeTestFile(t, filepath.Join(updateDir, "app"), "new binary")
+	writeTestFile(t, filepath.Join(updateDir, "config.yaml"), "default config")
+	writeTestFile(t, filepath.Join(updateDir, "lib", "plugin.so"), "plugin")
+
+	c := newTestClient(t, installDir)
+	journal, err := c.applyUpdate(updateDir, UpdateRecord{Version: "1.1.0", Status: UpdateStatusSuccess}, false)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if journal.State != applyStateCommitted {
+		t.Errorf("Expected journal state %q, got %q", applyStateCommitted, journal.State)
+	}
+	c.commitApply(journal)
+
+	assertFileContent(t, filepath.Join(installDir, "app"), "new binary")
+	assertFileContent(t, filepath.Join(installDir, "config.yaml"), "user config")
+	assertFileContent(t, filepath.Join(installDir, "lib", "plugin.so"), "plugin")
+	assertNoApplyLeftovers(t, installDir)
+}
+
+func TestApplyUpdateRollsBackOnFailure(t *testing.T) {
+	installDir := t.TempDir()
+	writeTestFile(t, filepath.Join(installDir, "app"), "old binary")
+	// lib是普通文件，暂存lib/plugin.so时无法创建父目录
+	writeTestFile(t, filepath.Join(installDir, "lib"), "not a directory")
+
+	updateDir := t.TempDir()
+	writeTestFile(t, filepath.Join(updateDir, "app"), "new binary")
+	writeTestFile(t, filepath.Join(updateDir, "lib", "plugin.so"), "plugin")
+	writeTestFile(t, filepath.Join(updateDir, "share", "data"), "data")
+
+	c := newTestClient(t, installDir)
+	if _, err := c.applyUpdate(updateDir, UpdateRecord{Version: "1.1.0"}, false); err == nil {
+		t.Fatal("Expected apply to fail")
+	}
+
+	assertFileContent(t, filepath.Join(installDir, "app"), "old binary")
+	assertFileContent(t, filepath.Join(installDir, "lib"), "not a directory")
+	if _, err := os.Stat(filepath.Join(installDir, "share")); !os.IsNotExist(err) {
+		t.Errorf("Expected created directory to be removed, got %v", err)
+	}
+	assertNoApplyLeftovers(t, installDir)
+}
+
+func TestRollbackApplyIgnoresUnswappedEntries(t *testing.T) {
+	installDir := t.TempDir()
+
+	// a已替换，替换b时失败；b旁残留着更早的更新未能删除的旧文件
+	writeTestFile(t, filepath.Join(installDir, "a"), "new a")
+	writeTestFile(t, filepath.Join(installDir, "a"+replacedSuffix), "old a")
+	writeTestFile(t, filepath.Join(installDir, "b"), "current b")
+	writeTestFile(t, filepath.Join(installDir, "b"+stagedSuffix), "new b")
+	writeTestFile(t, filepath.Join(installDir, "b"+replacedSuffix), "stale b")
+
+	c := newTestClient(t, installDir)
+	journal := &applyJournal{
+		State:   applyStateRollingBack,
+		Entries: []applyEntry{{Path: "a", Existed: true}, {Path: "b", Existed: true}},
+		Swapped: 1,
+	}
+	if err := c.rollbackApply(journal); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	assertFileContent(t, filepath.Join(installDir, "a"), "old a")
+	assertFileContent(t, filepath.Join(installDir, "b"), "current b")
+}
+
+func TestApplyUpdateRemovesStaleReplacedFiles(t *testing.T) {
+	installDir := t.TempDir()
+	writeTestFile(t, filepath.Join(installDir, "app"), "old binary")
+	writeTestFile(t, filepath.Join(installDir, "app"+replacedSuffix), "stale binary")
+
+	updateDir := t.TempDir()
+	writeTestFile(t, filepath.Join(updateDir, "app"), "new binary")
+
+	c := newTestClient(t, installDir)
+	journal, err := c.applyUpdate(updateDir, UpdateRecord{Version: "1.1.0"}, false)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	// 替换产生的旧文件是本次更新前的文件，而不是残留的旧文件
+	assertFileContent(t, filepath.Join(installDir, "app"+replacedSuffix), "old binary")
+	c.commitApply(journal)
+	assertNoApplyLeftovers(t, installDir)
+}
+
+// runInstalledCopy 将测试程序复制为installDir/app，并在子进程中运行名为helper的辅助测试，
+// 使辅助测试中被替换的文件就是正在运行的可执行文件
+func runInstalledCopy(t *testing.T, installDir, helper string) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("running executables cannot be deleted on Windows")
+	}
+
+	execPath, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	app := filepath.Join(installDir, "app")
+	if err := utils.CopyFile(execPath, app); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(app, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := exec.Command(app, "-test.run=^"+helper+"$")
+	cmd.Env = append(os.Environ(), selfUpdateDirEnv+"="+installDir)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("Expected %s to succeed, got %v\n%s", helper, err, output)
+	}
+}
+
+func TestUpdateRunningExecutable(t *testing.T) {
+	installDir := t.TempDir()
+	runInstalledCopy(t, installDir, "TestSelfUpdateHelper")
+
+	// 第二次更新仍写入app，而不是第一次更新后改名的旧文件
+	assertFileContent(t, filepath.Join(installDir, "app"), "second update")
+	assertNoApplyLeftovers(t, installDir)
+}
+
+// TestSelfUpdateHelper 由TestUpdateRunningExecutable在子进程中运行，连续两次更新正在运行的可执行文件
+func TestSelfUpdateHelper(t *testing.T) {
+	installDir := os.Getenv(selfUpdateDirEnv)
+	if installDir == "" {
+		t.Skip("helper process for TestUpdateRunningExecutable")
+	}
+
+	c := newTestClient(t, installDir)
+	for i, content := range []string{"first update", "second update"} {
+		packagePath := filepath.Join(t.TempDir(), "app")
+		writeTestFile(t, packagePath, content)
+
+		info := &UpdateInfo{LatestVersion: fmt.Sprintf("1.%d.0", i+1), CompressionType: "raw"}
+		if err := c.Update(context.Background(), info, packagePath); err != nil {
+			t.Fatalf("Update %d: expected no error, got %v", i+1, err)
+		}
+	}
+}
+
+func TestInstallRemovesFilesDroppedFromManifest(t *testing.T) {
+	installDir := t.TempDir()
+	writeTestFile(t, filepath.Join(installDir, "app"), "old binary")
+	writeTestFile(t, filepath.Join(installDir, "plugins", "old.so"), "old plugin")
+	writeTestFile(t, filepath.Join(installDir, "config.yaml"), "user config")
+	writeTestFile(t, filepath.Join(installDir, "user.txt"), "user file")
+
+	c := newTestClient(t, installDir)
+	c.manifests["1.0.0"] = []string{"app", "config.yaml", "plugins/old.so"}
+
+	updateDir := t.TempDir()
+	writeTestFile(t, filepath.Join(updateDir, "app"), "new binary")
+	writeTestFile(t, filepath.Join(updateDir, "lib", "new.so"), "new lib")
+
+	info := &UpdateInfo{LatestVersion: "1.1.0", CurrentVersion: "1.0.0"}
+	if err := c.installFromDir(context.Background(), info, updateDir, false); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	assertFileContent(t, filepath.Join(installDir, "app"), "new binary")
+	assertFileContent(t, filepath.Join(installDir, "config.yaml"), "user config")
+	assertFileContent(t, filepath.Join(installDir, "user.txt"), "user file")
+	if _, err := os.Stat(filepath.Join(installDir, "plugins")); !os.IsNotExist(err) {
+		t.Errorf("Expected removed plugin and its directory to be deleted, got %v", err)
+	}
+	assertNoApplyLeftovers(t, installDir)
+
+	// 清单持久化，补丁等部分更新合并清单而不删除文件
+	reloaded := newTestClient(t, installDir)
+	if manifest := reloaded.manifest("1.1.0"); strings.Join(manifest, ",") != "app,lib/new.so" {
+		t.Errorf("Expected manifest for 1.1.0, got %v", manifest)
+	}
+
+	patchDir := t.TempDir()
+	writeTestFile(t, filepath.Join(patchDir, "app"), "patched binary")
+	info = &UpdateInfo{LatestVersion: "1.1.1", CurrentVersion: "1.1.0"}
+	if err := reloaded.installFromDir(context.Background(), info, patchDir, true); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	assertFileContent(t, filepath.Join(installDir, "lib", "new.so"), "new lib")
+	if manifest := reloaded.manifest("1.1.1"); strings.Join(manifest, ",") != "app,lib/new.so" {
+		t.Errorf("Expected merged manifest for 1.1.1, got %v", manifest)
+	}
+}
+
+func TestRecoverApplyRollsForward(t *testing.T) {
+	installDir := t.TempDir()
+
+	// 模拟替换过程中断：a尚未替换，b已改名为旧文件，c已替换完成，d为新增文件
+	writeTestFile(t, filepath.Join(installDir, "a"), "old a")
+	writeTestFile(t, filepath.Join(installDir, "a"+stagedSuffix), "new a")
+	writeTestFile(t, filepath.Join(installDir, "b"+replacedSuffix), "old b")
+	writeTestFile(t, filepath.Join(installDir, "b"+stagedSuffix), "new b")
+	writeTestFile(t, filepath.Join(installDir, "c"), "new c")
+	writeTestFile(t, filepath.Join(installDir, "c"+replacedSuffix), "old c")
+	writeTestFile(t, filepath.Join(installDir, "d"+stagedSuffix), "new d")
+	writeApplyJournalFile(t, installDir, applyJournal{
+		State:  applyStateSwapping,
+		Record: &UpdateRecord{Version: "2.0.0", FromVersion: "1.0.0", Status: UpdateStatusSuccess},
+		Entries: []applyEntry{
+			{Path: "a", Existed: true},
+			{Path: "b", Existed: true},
+			{Path: "c", Existed: true},
+			{Path: "d"},
+		},
+	})
+
+	c := newTestClient(t, installDir)
+
+	for _, name := range []string{"a", "b", "c", "d"} {
+		assertFileContent(t, filepath.Join(installDir, name), "new "+name)
+	}
+	assertNoApplyLeftovers(t, installDir)
+	if version := c.GetInstalledVersion(); version != "2.0.0" {
+		t.Errorf("Expected installed version 2.0.0, got %q", version)
+	}
+}
+
+func TestRecoverApplyRollsBack(t *testing.T) {
+	installDir := t.TempDir()
+
+	// 模拟回滚过程中断：a已恢复，b仍是新文件，c为新增文件
+	writeTestFile(t, filepath.Join(installDir, "a"), "old a")
+	writeTestFile(t, filepath.Join(installDir, "b"), "new b")
+	writeTestFile(t, filepath.Join(installDir, "b"+replacedSuffix), "old b")
+	writeTestFile(t, filepath.Join(installDir, "sub", "c"), "new c")
+	writeApplyJournalFile(t, installDir, applyJournal{
+		State:   applyStateRollingBack,
+		Record:  &UpdateRecord{Version: "2.0.0", Status: UpdateStatusSuccess},
+		Entries: []applyEntry{{Path: "a", Existed: true}, {Path: "b", Existed: true}, {Path: filepath.Join("sub", "c")}},
+		Dirs:    []string{"sub"},
+		Swapped: 3,
+	})
+
+	c := newTestClient(t, installDir)
+
+	assertFileContent(t, filepath.Join(installDir, "a"), "old a")
+	assertFileContent(t, filepath.Join(installDir, "b"), "old b")
+	if _, err := os.Stat(filepath.Join(installDir, "sub")); !os.IsNotExist(err) {
+		t.Errorf("Expected added files to be removed, got %v", err)
+	}
+	assertNoApplyLeftovers(t, installDir)
+	if version := c.GetInstalledVersion(); version != "" {
+		t.Errorf("Expected installed version to be unchanged, got %q", version)
+	}
+}
@@ -0,0 +1,196 @@
+package client
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/CooperJiang/versiontrack-go-sdk/internal/utils"
+)
+
+// pendingBootName 待确认启动标记文件名称（位于状态目录下）
+const pendingBootName = "pending.json"
+
+// processToken 标识当前进程的随机值，同一进程中多次创建客户端只计为一次启动
+// 不使用进程ID：Restart通过exec重启时进程ID不变
+var processToken = newProcessToken()
+
+// newProcessToken 生成进程标识
+func newProcessToken() string {
+	buf := make([]byte, 16)
+	rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
+
+// pendingBoot 待确认的更新，新版本调用MarkHealthy后删除
+type pendingBoot struct {
+	// 待确认的版本号
+	Version string `json:"version"`
+	// 更新时间
+	UpdatedAt time.Time `json:"updatedAt"`
+	// 确认期限，新版本首次启动时设置
+	Deadline time.Time `json:"deadline"`
+	// 未确认时已启动的次数
+	BootAttempts int `json:"bootAttempts"`
+	// 执行更新或最近一次计入启动次数的进程标识，同一进程不重复计数
+	Process string `json:"process,omitempty"`
+}
+
+// pendingBootPath 返回待确认启动标记路径
+func (c *Client) pendingBootPath() string {
+	return filepath.Join(c.stateDir(), pendingBootName)
+}
+
+// MarkHealthy 确认当前安装的版本运行正常，删除待确认标记
+// 启用BootConfirm时，更新后的新版本必须在期限和最大启动次数内调用，否则下次启动时回滚
+func (c *Client) MarkHealthy() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := os.Remove(c.pendingBootPath()); err != nil && !os.IsNotExist(err) {
+		return NewClientError("STATE_SAVE_FAILED", "Failed to remove pending boot marker", err)
+	}
+	return nil
+}
+
+// BootRollback 返回NewClient因新版本未确认而回滚的更新记录，未回滚时返回nil
+// Status为rolled_back时当前进程运行的仍是被回滚版本的代码，应调用Restart执行已恢复的旧版本；
+// Status为failed时回滚失败（Error说明原因），新版本继续运行且不再要求确认
+func (c *Client) BootRollback() *UpdateRecord {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.bootRollback
+}
+
+// writePendingBoot 写入待确认启动标记，未启用BootConfirm时跳过
+func (c *Client) writePendingBoot(version string) error {
+	if !c.config.BootConfirm.Enabled {
+		return nil
+	}
+
+	// 执行更新的进程（仍是旧版本）再次创建客户端不计为新版本的启动
+	return c.savePendingBoot(&pendingBoot{
+		Version:   version,
+		UpdatedAt: time.Now(),
+		Process:   processToken,
+	})
+}
+
+// clearPendingBoot 删除待确认启动标记
+func (c *Client) clearPendingBoot() {
+	os.Remove(c.pendingBootPath())
+}
+
+// checkPendingBoot 启动时检查待确认的更新：新版本首次启动时从当前时间开始计算确认期限，
+// 之后超过确认期限或最大启动次数仍未确认时回滚到更新前的备份，否则增加启动次数
+// 每个进程只检查一次，同一进程中再次创建客户端时跳过
+func (c *Client) checkPendingBoot() error {
+	pending, err := c.readPendingBoot()
+	if err != nil || pending == nil {
+		return err
+	}
+
+	// 标记对应的版本已不是当前安装版本（如更新失败或已回滚），标记失效
+	if pending.Version != c.GetInstalledVersion() {
+		c.clearPendingBoot()
+		return nil
+	}
+
+	if pending.Process == processToken {
+		return nil
+	}
+	pending.Process = processToken
+
+	// 确认期限从新版本首次启动开始计算，更新后过了很久才重启也不会在首次启动时回滚
+	if pending.BootAttempts == 0 {
+		pending.BootAttempts = 1
+		pending.Deadline = time.Now().Add(c.config.BootConfirm.Timeout)
+		return c.savePendingBoot(pending)
+	}
+
+	var cause error
+	if pending.BootAttempts >= c.config.BootConfirm.MaxBootAttempts {
+		cause = fmt.Errorf("%w: version %s started %d times without calling MarkHealthy",
+			ErrBootNotConfirmed, pending.Version, pending.BootAttempts)
+	} else if time.Now().After(pending.Deadline) {
+		cause = fmt.Errorf("%w: version %s was not confirmed before %s",
+			ErrBootNotConfirmed, pending.Version, pending.Deadline.Format(time.RFC3339))
+	}
+
+	if cause == nil {
+		pending.BootAttempts++
+		return c.savePendingBoot(pending)
+	}
+
+	// 创建客户端时不发送请求，结果留在队列中由下次FlushReports上报
+	record, err := c.rollbackUnhealthy(cause)
+	if err != nil {
+		// 回滚失败时删除标记，让新版本继续运行，避免每次启动都因重试回滚失败而无法启动
+		c.clearPendingBoot()
+		record = c.failedBootRollback(pending, cause, err)
+		c.report(InstallReport{
+			FromVersion: record.FromVersion,
+			ToVersion:   record.Version,
+			Status:      UpdateStatusFailed,
+			DurationMs:  time.Since(pending.UpdatedAt).Milliseconds(),
+		}, err)
+	} else {
+		c.reportRollback(record, pending.UpdatedAt, cause)
+	}
+
+	c.mu.Lock()
+	c.bootRollback = record
+	c.mu.Unlock()
+	return nil
+}
+
+// failedBootRollback 返回回滚失败时通过BootRollback报告的记录
+func (c *Client) failedBootRollback(pending *pendingBoot, cause, err error) *UpdateRecord {
+	record := &UpdateRecord{
+		Version:   pending.Version,
+		UpdatedAt: pending.UpdatedAt,
+		Status:    UpdateStatusFailed,
+		Error:     fmt.Sprintf("%v; rollback failed: %v", cause, err),
+	}
+	for _, r := range c.GetUpdateHistory() {
+		if r.Version == pending.Version && r.Status == UpdateStatusSuccess {
+			record.FromVersion = r.FromVersion
+		}
+	}
+	return record
+}
+
+// readPendingBoot 读取待确认启动标记，不存在时返回nil
+func (c *Client) readPendingBoot() (*pendingBoot, error) {
+	data, err := os.ReadFile(c.pendingBootPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read pending boot marker: %w", err)
+	}
+
+	var pending pendingBoot
+	if err := json.Unmarshal(data, &pending); err != nil {
+		return nil, fmt.Errorf("failed to decode pending boot marker: %w", err)
+	}
+	return &pending, nil
+}
+
+// savePendingBoot 原子写入待确认启动标记
+func (c *Client) savePendingBoot(pending *pendingBoot) error {
+	data, err := json.MarshalIndent(pending, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode pending boot marker: %w", err)
+	}
+
+	if err := utils.WriteFileAtomic(c.pendingBootPath(), data, 0644); err != nil {
+		return fmt.Errorf("failed to write pending boot marker: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,194 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newBootClient(t *testing.T, installDir string, confirm BootConfirm) *Client {
+	t.Helper()
+
+	c, err := NewClient(&Config{
+		ServerURL:      "https://test-server.com",
+		APIKey:         "test-api-key",
+		Platform:       "linux",
+		Arch:           "amd64",
+		InstallDir:     installDir,
+		CurrentVersion: "1.0.0",
+		BootConfirm:    confirm,
+		DisableReports: true,
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	return c
+}
+
+// bootNewProcess 模拟新版本的一次进程启动并创建客户端
+func bootNewProcess(t *testing.T, installDir string, confirm BootConfirm) *Client {
+	t.Helper()
+
+	processToken = newProcessToken()
+	return newBootClient(t, installDir, confirm)
+}
+
+func installTestUpdate(t *testing.T, c *Client, installDir string) {
+	t.Helper()
+
+	writeTestFile(t, filepath.Join(installDir, "app"), "old binary")
+	updateDir := t.TempDir()
+	writeTestFile(t, filepath.Join(updateDir, "app"), "new binary")
+
+	info := &UpdateInfo{LatestVersion: "1.1.0", CurrentVersion: "1.0.0"}
+	if err := c.installFromDir(context.Background(), info, updateDir, false); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if _, err := os.Stat(c.pendingBootPath()); err != nil {
+		t.Fatalf("Expected pending boot marker, got %v", err)
+	}
+}
+
+func TestBootConfirmRollsBackUnconfirmedUpdate(t *testing.T) {
+	testCases := []struct {
+		name    string
+		confirm BootConfirm
+		boots   int
+	}{
+		{name: "crash on first boot", confirm: BootConfirm{Enabled: true}, boots: 1},
+		{name: "max boot attempts", confirm: BootConfirm{Enabled: true, MaxBootAttempts: 3}, boots: 3},
+		{name: "deadline", confirm: BootConfirm{Enabled: true, Timeout: time.Millisecond, MaxBootAttempts: 5}, boots: 1},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			installDir := t.TempDir()
+			installTestUpdate(t, newBootClient(t, installDir, tc.confirm), installDir)
+
+			// 新版本启动后未调用MarkHealthy即退出
+			for i := 0; i < tc.boots; i++ {
+				if c := bootNewProcess(t, installDir, tc.confirm); c.BootRollback() != nil {
+					t.Fatalf("Expected no rollback on boot %d", i+1)
+				}
+			}
+			time.Sleep(5 * time.Millisecond)
+
+			c := bootNewProcess(t, installDir, tc.confirm)
+			record := c.BootRollback()
+			if record == nil || record.Version != "1.1.0" || record.Status != UpdateStatusRolledBack {
+				t.Fatalf("Expected rolled back record, got %+v", record)
+			}
+			if !strings.Contains(record.Error, ErrBootNotConfirmed.Error()) {
+				t.Errorf("Expected failure reason, got %q", record.Error)
+			}
+			assertFileContent(t, filepath.Join(installDir, "app"), "old binary")
+			if version := c.GetInstalledVersion(); version != "1.0.0" {
+				t.Errorf("Expected installed version 1.0.0, got %q", version)
+			}
+			if _, err := os.Stat(c.pendingBootPath()); !errors.Is(err, os.ErrNotExist) {
+				t.Errorf("Expected pending boot marker to be removed, got %v", err)
+			}
+		})
+	}
+}
+
+func TestBootConfirmDeadlineStartsAtFirstBoot(t *testing.T) {
+	installDir := t.TempDir()
+	confirm := BootConfirm{Enabled: true, Timeout: 10 * time.Millisecond}
+	installTestUpdate(t, newBootClient(t, installDir, confirm), installDir)
+
+	// 更新后超过确认期限才重启，首次启动不回滚
+	time.Sleep(20 * time.Millisecond)
+	c := bootNewProcess(t, installDir, confirm)
+	if record := c.BootRollback(); record != nil {
+		t.Fatalf("Expected no rollback on first boot, got %+v", record)
+	}
+	assertFileContent(t, filepath.Join(installDir, "app"), "new binary")
+
+	pending, err := c.readPendingBoot()
+	if err != nil || pending == nil {
+		t.Fatalf("Expected pending boot marker, got %v", err)
+	}
+	if pending.BootAttempts != 1 || !pending.Deadline.After(pending.UpdatedAt.Add(confirm.Timeout)) {
+		t.Errorf("Expected deadline to start at first boot, got %+v", pending)
+	}
+}
+
+func TestBootConfirmCountsEachProcessOnce(t *testing.T) {
+	installDir := t.TempDir()
+	confirm := BootConfirm{Enabled: true}
+
+	// 执行更新的进程再次创建客户端不计为新版本的启动
+	installTestUpdate(t, newBootClient(t, installDir, confirm), installDir)
+	newBootClient(t, installDir, confirm)
+
+	// 新版本进程中多次创建客户端只计为一次启动
+	bootNewProcess(t, installDir, confirm)
+	c := newBootClient(t, installDir, confirm)
+	if record := c.BootRollback(); record != nil {
+		t.Fatalf("Expected no rollback within the same process, got %+v", record)
+	}
+	assertFileContent(t, filepath.Join(installDir, "app"), "new binary")
+
+	pending, err := c.readPendingBoot()
+	if err != nil || pending == nil || pending.BootAttempts != 1 {
+		t.Fatalf("Expected a single boot attempt, got %+v (%v)", pending, err)
+	}
+	if err := c.MarkHealthy(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+}
+
+func TestBootConfirmRollbackFailureDoesNotBlockStartup(t *testing.T) {
+	installDir := t.TempDir()
+	confirm := BootConfirm{Enabled: true}
+	c := newBootClient(t, installDir, confirm)
+	installTestUpdate(t, c, installDir)
+
+	// 备份丢失，回滚必然失败
+	history := c.GetUpdateHistory()
+	if err := os.Remove(history[len(history)-1].BackupPath); err != nil {
+		t.Fatal(err)
+	}
+
+	bootNewProcess(t, installDir, confirm)
+	c = bootNewProcess(t, installDir, confirm)
+	record := c.BootRollback()
+	if record == nil || record.Status != UpdateStatusFailed || record.FromVersion != "1.0.0" ||
+		!strings.Contains(record.Error, "rollback failed") {
+		t.Fatalf("Expected failed rollback record, got %+v", record)
+	}
+	assertFileContent(t, filepath.Join(installDir, "app"), "new binary")
+	if _, err := os.Stat(c.pendingBootPath()); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Expected pending boot marker to be removed, got %v", err)
+	}
+
+	// 之后的启动不再尝试回滚
+	if record := bootNewProcess(t, installDir, confirm).BootRollback(); record != nil {
+		t.Errorf("Expected no rollback on later boots, got %+v", record)
+	}
+}
+
+func TestMarkHealthyConfirmsUpdate(t *testing.T) {
+	installDir := t.TempDir()
+	confirm := BootConfirm{Enabled: true}
+	installTestUpdate(t, newBootClient(t, installDir, confirm), installDir)
+
+	booted := bootNewProcess(t, installDir, confirm)
+	if err := booted.MarkHealthy(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	c := bootNewProcess(t, installDir, confirm)
+	if record := c.BootRollback(); record != nil {
+		t.Errorf("Expected no rollback, got %+v", record)
+	}
+	assertFileContent(t, filepath.Join(installDir, "app"), "new binary")
+	if version := c.GetInstalledVersion(); version != "1.1.0" {
+		t.Errorf("Expected installed version 1.1.0, got %q", version)
+	}
+}
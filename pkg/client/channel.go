@@ -0,0 +1,46 @@
+package client
+
+import (
+	"github.com/CooperJiang/versiontrack-go-sdk/pkg/version"
+)
+
+// 发布渠道，稳定性依次降低，客户端会接收其所在渠道及更稳定渠道的版本
+const (
+	ChannelStable  = "stable"  // 正式版本
+	ChannelBeta    = "beta"    // 测试版本（含预发布版本）
+	ChannelPreview = "preview" // 预览版本（含尚未正式发布的scheduled/draft版本）
+)
+
+// channelRanks 渠道的稳定性排序，数值越大越不稳定
+var channelRanks = map[string]int{
+	ChannelStable:  0,
+	ChannelBeta:    1,
+	ChannelPreview: 2,
+}
+
+// versionChannel 返回版本所属的渠道
+// 优先使用服务器返回的channel；未返回时scheduled/draft状态的版本属于preview，
+// 带预发布标识的版本（如1.5.0-beta.1）属于beta，其余属于stable
+func versionChannel(v *VersionInfo) string {
+	if v.Channel != "" {
+		return v.Channel
+	}
+
+	switch v.Status {
+	case "scheduled", "draft":
+		return ChannelPreview
+	}
+	if parsed, err := version.Parse(v.Version); err == nil && parsed.IsPrerelease() {
+		return ChannelBeta
+	}
+	return ChannelStable
+}
+
+// channelIncludes 检查客户端所在渠道是否接收该版本，未知渠道的版本只有preview渠道接收
+func (c *Client) channelIncludes(v *VersionInfo) bool {
+	rank, ok := channelRanks[versionChannel(v)]
+	if !ok {
+		rank = channelRanks[ChannelPreview]
+	}
+	return rank <= channelRanks[c.config.Channel]
+}
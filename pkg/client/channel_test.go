@@ -0,0 +1,125 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestVersionChannel(t *testing.T) {
+	testCases := []struct {
+		info     VersionInfo
+		expected string
+	}{
+		{VersionInfo{Version: "1.2.0", Status: "published"}, ChannelStable},
+		{VersionInfo{Version: "1.3.0-beta.1", Status: "published"}, ChannelBeta},
+		{VersionInfo{Version: "1.3.0", Status: "scheduled"}, ChannelPreview},
+		{VersionInfo{Version: "1.3.0", Status: "draft"}, ChannelPreview},
+		{VersionInfo{Version: "1.3.0-rc.1", Channel: ChannelStable}, ChannelStable},
+		{VersionInfo{Version: "nightly"}, ChannelStable},
+	}
+	for _, tc := range testCases {
+		if got := versionChannel(&tc.info); got != tc.expected {
+			t.Errorf("versionChannel(%+v): expected %s, got %s", tc.info, tc.expected, got)
+		}
+	}
+}
+
+func TestChannelFiltersUpdates(t *testing.T) {
+	var channels []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		channels = append(channels, r.URL.Query().Get("channel"))
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"code":    200,
+			"message": "ok",
+			"data": UpdatesInfo{
+				HasUpdate:     true,
+				LatestVersion: "1.4.0",
+				AvailableVersions: []VersionInfo{
+					{Version: "1.4.0", Status: "scheduled", Changelog: "1.4.0 notes", DownloadURL: "https://example.com/1.4.0.tar.gz"},
+					{Version: "1.3.0-beta.2", Status: "published", Changelog: "1.3.0-beta.2 notes", DownloadURL: "https://example.com/1.3.0-beta.2.tar.gz"},
+					{Version: "1.2.0", Status: "published", Changelog: "1.2.0 notes", DownloadURL: "https://example.com/1.2.0.tar.gz"},
+				},
+			},
+		})
+	}))
+	defer server.Close()
+
+	testCases := []struct {
+		channel   string
+		latest    string
+		available int
+	}{
+		{"", "1.2.0", 1},
+		{ChannelBeta, "1.3.0-beta.2", 2},
+		{ChannelPreview, "1.4.0", 3},
+	}
+	for _, tc := range testCases {
+		c, err := NewClient(&Config{
+			ServerURL:  server.URL,
+			APIKey:     "test-api-key",
+			Platform:   "linux",
+			Arch:       "amd64",
+			InstallDir: t.TempDir(),
+			Channel:    tc.channel,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		updates, err := c.CheckForMultipleUpdates(context.Background(), "1.1.0")
+		if err != nil {
+			t.Fatalf("channel %q: expected no error, got %v", tc.channel, err)
+		}
+		if updates.LatestVersion != tc.latest || len(updates.AvailableVersions) != tc.available {
+			t.Errorf("channel %q: expected latest %s with %d versions, got %s with %+v",
+				tc.channel, tc.latest, tc.available, updates.LatestVersion, updates.AvailableVersions)
+		}
+
+		// 旧接口同样按渠道过滤，兼容字段来自过滤后的最新版本
+		info, err := c.CheckForUpdates(context.Background(), "1.1.0")
+		if err != nil {
+			t.Fatalf("channel %q: expected no error, got %v", tc.channel, err)
+		}
+		if info.LatestVersion != tc.latest || len(info.AvailableVersions) != tc.available ||
+			info.ReleaseNotes != tc.latest+" notes" || info.DownloadURL != "https://example.com/"+tc.latest+".tar.gz" {
+			t.Errorf("channel %q: expected CheckForUpdates to offer %s, got %+v", tc.channel, tc.latest, info)
+		}
+
+		if tc.channel == "" {
+			err = c.UpdateToVersion(context.Background(), "1.4.0", nil)
+			if !errors.Is(err, ErrVersionNotAllowed) {
+				t.Errorf("Expected preview version to be rejected on stable channel, got %v", err)
+			}
+		}
+	}
+
+	// 每个客户端调用CheckForMultipleUpdates和CheckForUpdates，默认渠道的客户端还调用了UpdateToVersion
+	expected := []string{ChannelStable, ChannelStable, ChannelStable, ChannelBeta, ChannelBeta, ChannelPreview, ChannelPreview}
+	if len(channels) != len(expected) {
+		t.Fatalf("Expected %d requests, got channels %v", len(expected), channels)
+	}
+	for i, channel := range expected {
+		if channels[i] != channel {
+			t.Errorf("Expected channel query %s for request %d, got %v", channel, i, channels)
+		}
+	}
+}
+
+func TestInvalidChannel(t *testing.T) {
+	_, err := NewClient(&Config{
+		ServerURL:  "https://test-server.com",
+		APIKey:     "test-api-key",
+		Platform:   "linux",
+		Arch:       "amd64",
+		InstallDir: t.TempDir(),
+		Channel:    "nightly",
+	})
+	if err == nil {
+		t.Error("Expected invalid channel to be rejected")
+	}
+}
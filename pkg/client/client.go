@@ -2,15 +2,21 @@ package client
 
 import (
 	"context"
+	"crypto"
 	"fmt"
+	"hash"
+	"net/url"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/CooperJiang/versiontrack-go-sdk/internal/archive"
 	"github.com/CooperJiang/versiontrack-go-sdk/internal/http"
 	"github.com/CooperJiang/versiontrack-go-sdk/internal/utils"
+	"github.com/CooperJiang/versiontrack-go-sdk/internal/verify"
 )
 
 // Updater 更新器接口
@@ -24,7 +30,7 @@ type Updater interface {
 	// GetRecommendedUpdate 获取推荐更新版本（自动模式）
 	GetRecommendedUpdate(ctx context.Context, currentVersion string) (*VersionInfo, error)
 	
-	// UpdateToVersion 手动选择版本更新
+	// UpdateToVersion 手动选择版本更新，targetVersion可以是具体版本或版本范围约束（如"~1.5"）
 	UpdateToVersion(ctx context.Context, targetVersion string, callback ProgressCallback) error
 	
 	// HasForcedUpdate 检查是否有强制更新
@@ -41,6 +47,9 @@ type Updater interface {
 	
 	// GetUpdateHistory 获取更新历史
 	GetUpdateHistory() []UpdateRecord
+
+	// GetInstalledVersion 获取当前已安装的版本
+	GetInstalledVersion() string
 	
 	// Rollback 回滚到指定版本
 	Rollback(ctx context.Context, version string) error
@@ -48,9 +57,27 @@ type Updater interface {
 
 // Client VersionTrack客户端
 type Client struct {
-	config     *Config
-	httpClient *http.Client
-	history    []UpdateRecord
+	config      *Config
+	httpClient  *http.Client
+	installDir  string
+	// execPath 创建客户端时（替换任何文件之前）记录的可执行文件路径，execErr为获取失败的原因
+	execPath    string
+	execErr     error
+	trustedKeys []crypto.PublicKey
+	policy      versionPolicy
+	// installationID 本安装实例的唯一ID，用于灰度分桶
+	installationID string
+
+	// mu 保护以下持久化状态及关闭钩子
+	mu               sync.Mutex
+	history          []UpdateRecord
+	installedVersion string
+	manifests        map[string][]string
+	bootRollback     *UpdateRecord
+	shutdownHooks    []ShutdownHook
+
+	// reportMu 保护待上报结果的磁盘队列
+	reportMu sync.Mutex
 }
 
 // NewClient 创建新的客户端实例
@@ -63,26 +90,169 @@ func NewClient(config *Config) (*Client, error) {
 	if config.Timeout == 0 {
 		config.Timeout = 30 * time.Second
 	}
+	if config.ConnectTimeout == 0 {
+		config.ConnectTimeout = 10 * time.Second
+	}
+	if config.DownloadStallTimeout == 0 {
+		config.DownloadStallTimeout = 60 * time.Second
+	}
 	if config.PreserveFiles == nil {
 		config.PreserveFiles = []string{"config.yaml", "config.yml", "*.conf"}
 	}
 	if config.BackupCount == 0 {
 		config.BackupCount = 3
 	}
+	if config.HealthCheck.Timeout == 0 {
+		config.HealthCheck.Timeout = 30 * time.Second
+	}
+	if config.HealthCheck.Interval == 0 {
+		config.HealthCheck.Interval = time.Second
+	}
+	if config.BootConfirm.Timeout == 0 {
+		config.BootConfirm.Timeout = 10 * time.Minute
+	}
+	if config.BootConfirm.MaxBootAttempts == 0 {
+		config.BootConfirm.MaxBootAttempts = 1
+	}
+
+	// 替换运行中的可执行文件后os.Executable会返回改名后的旧文件路径，因此在创建客户端时记录一次
+	execPath, execErr := executablePath()
+
+	installDir, err := resolveInstallDir(config.InstallDir, execPath, execErr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve install directory: %w", err)
+	}
+
+	trustedKeys, err := parseTrustedKeys(config.TrustedPublicKeys)
+	if err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
+
+	policy, err := parseVersionPolicy(config)
+	if err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
+
+	httpClient := http.NewClient(config.ServerURL, http.Options{
+		Timeout:        config.Timeout,
+		ConnectTimeout: config.ConnectTimeout,
+		StallTimeout:   config.DownloadStallTimeout,
+		Retry:          retryPolicy(config.Retry),
+	})
+
+	c := &Client{
+		config:      config,
+		httpClient:  httpClient,
+		installDir:  installDir,
+		execPath:    execPath,
+		execErr:     execErr,
+		trustedKeys: trustedKeys,
+		policy:      policy,
+		history:     make([]UpdateRecord, 0),
+		manifests:   make(map[string][]string),
+	}
+
+	// 读取或生成安装ID
+	if err := c.loadInstallationID(); err != nil {
+		return nil, fmt.Errorf("failed to load installation id: %w", err)
+	}
+
+	// 加载持久化的更新历史和已安装版本
+	if err := c.loadState(); err != nil {
+		return nil, fmt.Errorf("failed to load state: %w", err)
+	}
+
+	// 完成或回滚上次中断的更新
+	if err := c.recoverApply(); err != nil {
+		return nil, fmt.Errorf("failed to recover interrupted update: %w", err)
+	}
+
+	// 回滚上次更新后未确认运行正常的版本
+	if err := c.checkPendingBoot(); err != nil {
+		return nil, fmt.Errorf("failed to check pending update: %w", err)
+	}
+
+	return c, nil
+}
+
+// resolveInstallDir 解析安装目录，未配置时使用可执行文件所在目录
+func resolveInstallDir(dir, execPath string, execErr error) (string, error) {
+	if dir != "" {
+		return filepath.Abs(dir)
+	}
+	if execErr != nil {
+		return "", execErr
+	}
 
-	httpClient := http.NewClient(config.ServerURL, config.Timeout)
+	return filepath.Dir(execPath), nil
+}
 
-	return &Client{
-		config:     config,
-		httpClient: httpClient,
-		history:    make([]UpdateRecord, 0),
-	}, nil
+// executablePath 返回当前进程的可执行文件路径
+// 同一进程中之前的更新已替换可执行文件时，返回的是改名后的旧文件路径，去掉旧文件后缀即为新安装的文件
+func executablePath() (string, error) {
+	execPath, err := utils.GetExecutablePath()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(execPath, replacedSuffix), nil
+}
+
+// retryPolicy 将配置的重试策略转换为HTTP层的策略，零值字段使用默认值
+func retryPolicy(policy RetryPolicy) http.RetryPolicy {
+	result := http.DefaultRetryPolicy()
+	if policy.MaxAttempts > 0 {
+		result.MaxAttempts = policy.MaxAttempts
+	}
+	if policy.BaseBackoff > 0 {
+		result.BaseBackoff = policy.BaseBackoff
+	}
+	if policy.MaxBackoff > 0 {
+		result.MaxBackoff = policy.MaxBackoff
+	}
+	if policy.Jitter > 0 {
+		result.Jitter = policy.Jitter
+	}
+	if policy.RetryableStatusCodes != nil {
+		result.RetryableStatusCodes = policy.RetryableStatusCodes
+	}
+	return result
+}
+
+// parseTrustedKeys 解析配置中的受信任公钥
+func parseTrustedKeys(keys []string) ([]crypto.PublicKey, error) {
+	parsed := make([]crypto.PublicKey, 0, len(keys))
+	for i, key := range keys {
+		publicKey, err := verify.ParsePublicKey(key)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted public key #%d: %w", i, err)
+		}
+		parsed = append(parsed, publicKey)
+	}
+	return parsed, nil
+}
+
+// RegisterHashAlgorithm 注册自定义文件哈希算法，strength越大在与服务端协商时优先级越高
+func RegisterHashAlgorithm(name string, strength int, factory func() hash.Hash) {
+	verify.RegisterHash(name, strength, factory)
+}
+
+// checkPath 构建检查更新的请求路径
+func (c *Client) checkPath(currentVersion string) string {
+	query := url.Values{}
+	query.Set("platform", c.config.Platform)
+	query.Set("arch", c.config.Arch)
+	query.Set("currentVersion", currentVersion)
+	query.Set("channel", c.config.Channel)
+	query.Set("bucket", strconv.Itoa(c.RolloutBucket()))
+	// 告知服务端支持的哈希算法（按优先级排序），便于服务端返回更强的文件哈希
+	query.Set("hashAlgorithms", strings.Join(verify.SupportedHashes(!c.config.RequireStrongHash), ","))
+
+	return "/api/v1/public/versions/check?" + query.Encode()
 }
 
 // CheckForUpdates 检查是否有可用更新
 func (c *Client) CheckForUpdates(ctx context.Context, currentVersion string) (*UpdateInfo, error) {
-	url := fmt.Sprintf("/api/v1/public/versions/check?platform=%s&arch=%s&currentVersion=%s",
-		c.config.Platform, c.config.Arch, currentVersion)
+	path := c.checkPath(currentVersion)
 
 	var result struct {
 		Code    int         `json:"code"`
@@ -90,7 +260,7 @@ func (c *Client) CheckForUpdates(ctx context.Context, currentVersion string) (*U
 		Data    *UpdateInfo `json:"data"`
 	}
 
-	if err := c.httpClient.GetWithAuth(ctx, url, c.config.APIKey, &result); err != nil {
+	if err := c.httpClient.GetWithAuth(ctx, path, c.config.APIKey, &result); err != nil {
 		return nil, NewClientError("CHECK_FAILED", "Failed to check for updates", err)
 	}
 
@@ -103,6 +273,19 @@ func (c *Client) CheckForUpdates(ctx context.Context, currentVersion string) (*U
 		return nil, NewClientError("API_ERROR", "No update data returned", nil)
 	}
 
+	// 与CheckForMultipleUpdates相同地按渠道、允许范围和灰度过滤可用版本，兼容字段只从过滤后的版本获取
+	updates := &UpdatesInfo{
+		HasUpdate:         updateInfo.HasUpdate,
+		LatestVersion:     updateInfo.LatestVersion,
+		UpdateFiles:       updateInfo.UpdateFiles,
+		AvailableVersions: updateInfo.AvailableVersions,
+	}
+	c.filterUpdates(updates)
+	updateInfo.HasUpdate = updates.HasUpdate
+	updateInfo.LatestVersion = updates.LatestVersion
+	updateInfo.UpdateFiles = updates.UpdateFiles
+	updateInfo.AvailableVersions = updates.AvailableVersions
+
 	// 填充兼容字段 - 从第一个可用版本获取信息
 	if len(updateInfo.AvailableVersions) > 0 {
 		firstVersion := updateInfo.AvailableVersions[0]
@@ -116,11 +299,56 @@ func (c *Client) CheckForUpdates(ctx context.Context, currentVersion string) (*U
 		updateInfo.DownloadURL = firstVersion.DownloadURL
 		updateInfo.FileSize = firstVersion.FileSize
 		updateInfo.MD5Hash = firstVersion.FileHash
+		updateInfo.FileHash = firstVersion.FileHash
+		updateInfo.Signature = firstVersion.Signature
+		updateInfo.SignatureAlgorithm = firstVersion.SignatureAlgorithm
+	}
+
+	// 从匹配当前平台的更新包获取压缩类型，版本信息中没有签名时同时获取签名
+	if file := c.packageFile(updateInfo.UpdateFiles); file != nil {
+		updateInfo.CompressionType = compressionType(file)
+		if updateInfo.Signature == "" {
+			updateInfo.Signature = file.Signature
+			updateInfo.SignatureAlgorithm = file.SignatureAlgorithm
+		}
 	}
 
 	return updateInfo, nil
 }
 
+// matchingFile 返回与当前平台和架构匹配的更新文件
+func (c *Client) matchingFile(files []UpdateFile) *UpdateFile {
+	for i := range files {
+		file := &files[i]
+		if (file.Platform == "" || file.Platform == c.config.Platform) &&
+			(file.Arch == "" || file.Arch == c.config.Arch) {
+			return file
+		}
+	}
+	return nil
+}
+
+// packageFile 返回与当前平台和架构匹配的完整更新包文件（不含差分补丁）
+func (c *Client) packageFile(files []UpdateFile) *UpdateFile {
+	for i := range files {
+		if files[i].FileType == FileTypePatch {
+			continue
+		}
+		if file := c.matchingFile(files[i : i+1]); file != nil {
+			return file
+		}
+	}
+	return nil
+}
+
+// compressionType 返回更新文件的压缩类型，未压缩的文件视为raw（单个可执行文件）
+func compressionType(file *UpdateFile) string {
+	if file.CompressionType == "" && !file.IsCompressed {
+		return string(archive.FormatRaw)
+	}
+	return file.CompressionType
+}
+
 // buildDownloadURL 构建下载URL
 func buildDownloadURL(serverURL, fileID string) string {
 	return fmt.Sprintf("%s/api/v1/public/versions/files/%s/download", serverURL, fileID)
@@ -136,60 +364,170 @@ func (c *Client) Download(ctx context.Context, info *UpdateInfo, destPath string
 		return ErrNoUpdateAvailable
 	}
 
+	expectedHash := info.FileHash
+	if expectedHash == "" {
+		expectedHash = info.MD5Hash
+	}
+
+	// 下载文件 - 使用带认证的下载
+	return c.downloadAndVerify(ctx, info.DownloadURL, destPath, info.FileSize, expectedHash, callback)
+}
+
+// downloadAndVerify 下载文件到destPath.part（支持断点续传），校验通过后再重命名为destPath
+// 下载中断时保留.part文件以便下次续传；校验失败时删除.part文件
+func (c *Client) downloadAndVerify(ctx context.Context, downloadURL, destPath string, size int64, expectedHash string, callback ProgressCallback) error {
 	// 创建目标目录
 	if err := utils.EnsureDir(filepath.Dir(destPath)); err != nil {
 		return NewClientError("CREATE_DIR_FAILED", "Failed to create destination directory", err)
 	}
 
-	// 下载文件 - 使用带认证的下载
-	if err := c.httpClient.DownloadWithAuth(ctx, info.DownloadURL, c.config.APIKey, destPath, info.FileSize, func(downloaded, total int64) {
-		if callback != nil {
-			progress := &DownloadProgress{
-				Downloaded: downloaded,
-				Total:      total,
-				Speed:      0, // TODO: 计算下载速度
-				Percentage: float64(downloaded) / float64(total) * 100,
-			}
-			callback(progress)
-		}
-	}); err != nil {
+	tracker := c.newProgressTracker(callback)
+	partPath := destPath + ".part"
+	if err := c.httpClient.DownloadWithAuth(ctx, downloadURL, c.config.APIKey, partPath, size, tracker.httpCallback()); err != nil {
 		return NewClientError("DOWNLOAD_FAILED", "Failed to download update file", err)
 	}
+	tracker.finish()
 
 	// 验证文件
-	if err := utils.VerifyFileMD5(destPath, info.MD5Hash); err != nil {
-		return NewClientError("VERIFY_FAILED", "File verification failed", err)
+	if err := c.verifyHash(partPath, expectedHash); err != nil {
+		utils.RemoveFile(partPath)
+		return err
+	}
+
+	if err := os.Rename(partPath, destPath); err != nil {
+		return NewClientError("DOWNLOAD_FAILED", "Failed to move downloaded file into place", err)
+	}
+
+	return nil
+}
+
+// verifyHash 按哈希值中的算法校验文件，未提供哈希时仅在RequireStrongHash关闭时放行
+func (c *Client) verifyHash(path, expected string) error {
+	if expected == "" {
+		if c.config.RequireStrongHash {
+			return NewClientError("VERIFY_FAILED", "File hash is missing", ErrVerificationFailed)
+		}
+		return nil
+	}
+
+	algorithm, _, err := verify.ParseHash(expected)
+	if err != nil {
+		return NewClientError("VERIFY_FAILED", "Invalid file hash",
+			fmt.Errorf("%w: %v", ErrVerificationFailed, err))
+	}
+	if c.config.RequireStrongHash && verify.IsWeakHash(algorithm) {
+		return NewClientError("VERIFY_FAILED", fmt.Sprintf("Weak hash algorithm %s is not allowed", algorithm),
+			ErrVerificationFailed)
+	}
+
+	if err := verify.VerifyFileHash(path, expected); err != nil {
+		return NewClientError("VERIFY_FAILED", "File verification failed",
+			fmt.Errorf("%w: %v", ErrVerificationFailed, err))
 	}
 
 	return nil
 }
 
-// Update 执行更新
+// Update 执行更新，并向服务器上报更新结果
 func (c *Client) Update(ctx context.Context, info *UpdateInfo, downloadPath string) error {
 	if info == nil {
 		return NewClientError("INVALID_INFO", "Update info is nil", nil)
 	}
 
-	// 1. 创建备份
-	backupPath, err := c.createBackup()
-	if err != nil {
-		return NewClientError("BACKUP_FAILED", "Failed to create backup", err)
+	start, fromVersion := time.Now(), c.fromVersion(info)
+	err := c.update(ctx, info, downloadPath)
+	c.reportUpdate(info, fromVersion, start, err)
+	c.flushReportsBriefly(ctx)
+	return err
+}
+
+// update 校验、解压并安装已下载的更新包
+func (c *Client) update(ctx context.Context, info *UpdateInfo, downloadPath string) error {
+	// 校验签名（配置了受信任公钥时）
+	if err := c.verifySignature(downloadPath, info.Signature, info.SignatureAlgorithm); err != nil {
+		return err
 	}
 
-	// 2. 解压更新文件
+	// 解压更新文件
 	tempDir, err := utils.CreateTempDir("versiontrack-update")
 	if err != nil {
 		return NewClientError("CREATE_TEMP_FAILED", "Failed to create temp directory", err)
 	}
 	defer utils.RemoveTempDir(tempDir)
 
-	if err := archive.ExtractTarGz(downloadPath, tempDir); err != nil {
-		return NewClientError("EXTRACT_FAILED", "Failed to extract update file", err)
+	// 按压缩类型、文件扩展名或文件头选择解压方式
+	format, err := archive.DetectFormat(downloadPath, info.CompressionType)
+	if err != nil {
+		return NewClientError("EXTRACT_FAILED", "Unsupported update file format", fmt.Errorf("%w: %w", ErrExtractionFailed, err))
 	}
 
-	// 3. 应用更新
-	if err := c.applyUpdate(tempDir); err != nil {
-		// 更新失败，尝试回滚
+	if format.IsSingleFile() {
+		// 单文件更新包（未压缩或仅gzip压缩的可执行文件）直接作为当前可执行文件安装
+		name, err := c.executableName()
+		if err != nil {
+			return NewClientError("EXTRACT_FAILED", "Failed to resolve executable path", err)
+		}
+		if err := archive.ExtractFile(downloadPath, filepath.Join(tempDir, name), format, 0755, c.extractOptions()); err != nil {
+			return NewClientError("EXTRACT_FAILED", "Failed to extract update file", fmt.Errorf("%w: %w", ErrExtractionFailed, err))
+		}
+	} else if err := archive.Extract(downloadPath, tempDir, format, c.extractOptions()); err != nil {
+		return NewClientError("EXTRACT_FAILED", "Failed to extract update file", fmt.Errorf("%w: %w", ErrExtractionFailed, err))
+	}
+
+	// 单文件更新包只包含可执行文件，其余文件保持不变
+	return c.installFromDir(ctx, info, tempDir, format.IsSingleFile())
+}
+
+// extractOptions 返回解压更新包使用的选项
+func (c *Client) extractOptions() archive.Options {
+	return archive.Options{
+		AllowSetuid:  c.config.AllowSetuid,
+		MaxTotalSize: c.config.ExtractLimits.MaxTotalSize,
+		MaxFileSize:  c.config.ExtractLimits.MaxFileSize,
+		MaxEntries:   c.config.ExtractLimits.MaxEntries,
+		MaxDepth:     c.config.ExtractLimits.MaxDepth,
+	}
+}
+
+// executableName 返回当前可执行文件相对安装目录的路径，不在安装目录下时使用文件名
+func (c *Client) executableName() (string, error) {
+	execPath, err := c.execPath, c.execErr
+	if err != nil {
+		return "", err
+	}
+
+	if relPath, err := filepath.Rel(c.installDir, execPath); err == nil && filepath.IsLocal(relPath) {
+		return relPath, nil
+	}
+	return filepath.Base(execPath), nil
+}
+
+// installFromDir 将updateDir中已校验的文件安装到安装目录：备份、应用、记录历史、清理旧备份
+// partial为true时updateDir只包含部分文件（补丁或单文件更新），不删除旧版本中的其他文件
+func (c *Client) installFromDir(ctx context.Context, info *UpdateInfo, updateDir string, partial bool) error {
+	// 1. 创建备份
+	backupPath, err := c.createBackup()
+	if err != nil {
+		return NewClientError("BACKUP_FAILED", "Failed to create backup", err)
+	}
+
+	// 2. 应用更新
+	version := info.LatestVersion // 现在是字符串类型
+	record := UpdateRecord{
+		Version:     version,
+		FromVersion: c.fromVersion(info),
+		UpdatedAt:   time.Now(),
+		Status:      UpdateStatusSuccess,
+		BackupPath:  backupPath,
+	}
+	// 先写入待确认标记，应用过程中断后恢复的新版本同样需要确认
+	if err := c.writePendingBoot(version); err != nil {
+		return NewClientError("STATE_SAVE_FAILED", "Failed to write pending boot marker", err)
+	}
+	journal, err := c.applyUpdate(updateDir, record, partial)
+	if err != nil {
+		c.clearPendingBoot()
+		// 更新失败时已替换的文件会被事务回滚，再从备份恢复以防回滚不完整
 		if rollbackErr := c.restoreBackup(backupPath); rollbackErr != nil {
 			return NewClientError("UPDATE_AND_ROLLBACK_FAILED", 
 				fmt.Sprintf("Update failed: %v, Rollback also failed: %v", err, rollbackErr), nil)
@@ -197,48 +535,136 @@ func (c *Client) Update(ctx context.Context, info *UpdateInfo, downloadPath stri
 		return NewClientError("UPDATE_FAILED", "Update failed, rolled back successfully", err)
 	}
 
-	// 4. 记录更新历史
-	version := info.LatestVersion // 现在是字符串类型
-	record := UpdateRecord{
-		Version:    version,
-		UpdatedAt:  time.Now(),
-		Status:     "success",
-		BackupPath: backupPath,
+	// 3. 记录更新历史，然后删除被替换的旧文件和应用日志
+	if err := c.recordUpdate(record, journal.Manifest); err != nil {
+		return NewClientError("STATE_SAVE_FAILED", "Update applied but failed to save state", err)
+	}
+	c.commitApply(journal)
+
+	// 4. 冒烟测试，失败时恢复备份
+	if err := c.runSmokeTest(ctx); err != nil {
+		_, err = c.healthCheckFailed(err)
+		return err
 	}
-	c.history = append(c.history, record)
 
 	// 5. 清理旧备份
-	c.cleanupOldBackups()
+	if err := c.cleanupOldBackups(); err != nil {
+		return NewClientError("STATE_SAVE_FAILED", "Update applied but failed to save state", err)
+	}
 
 	return nil
 }
 
+// verifySignature 使用受信任公钥校验文件签名，未配置公钥时跳过
+func (c *Client) verifySignature(path, signature, algorithm string) error {
+	if len(c.trustedKeys) == 0 {
+		return nil
+	}
+
+	if signature == "" {
+		return NewClientError("SIGNATURE_INVALID", "Update package is not signed", ErrSignatureMissing)
+	}
+
+	if err := verify.VerifyFileSignature(path, signature, algorithm, c.trustedKeys); err != nil {
+		return NewClientError("SIGNATURE_INVALID", "Update package signature verification failed",
+			fmt.Errorf("%w: %v", ErrSignatureInvalid, err))
+	}
+
+	return nil
+}
+
+// fromVersion 返回更新前的版本，优先使用本地记录的已安装版本
+func (c *Client) fromVersion(info *UpdateInfo) string {
+	if version := c.GetInstalledVersion(); version != "" {
+		return version
+	}
+	return info.CurrentVersion
+}
+
+// recordUpdate 追加更新记录并持久化，manifest不为nil时同时保存该版本的文件清单
+func (c *Client) recordUpdate(record UpdateRecord, manifest []string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.history = append(c.history, record)
+	if record.Status == UpdateStatusSuccess {
+		c.installedVersion = record.Version
+	}
+	if manifest != nil {
+		c.manifests[record.Version] = manifest
+	}
+
+	return c.saveState()
+}
+
 // GetUpdateHistory 获取更新历史
 func (c *Client) GetUpdateHistory() []UpdateRecord {
-	return c.history
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	history := make([]UpdateRecord, len(c.history))
+	copy(history, c.history)
+	return history
+}
+
+// GetInstalledVersion 获取当前已安装的版本
+// 优先返回通过SDK安装并记录的版本，未记录时返回Config.CurrentVersion
+func (c *Client) GetInstalledVersion() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.installedVersion != "" {
+		return c.installedVersion
+	}
+	return c.config.CurrentVersion
 }
 
-// Rollback 回滚到指定版本
+// Rollback 回滚到指定版本，并向服务器上报该版本已回滚
 func (c *Client) Rollback(ctx context.Context, version string) error {
+	start := time.Now()
+	record, err := c.rollback(version)
+	if err != nil {
+		return err
+	}
+
+	c.reportRollback(record, start, nil)
+	c.flushReportsBriefly(ctx)
+	return nil
+}
+
+// rollback 恢复指定版本更新前的备份，返回被回滚的更新记录
+func (c *Client) rollback(version string) (*UpdateRecord, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
 	// 查找对应版本的备份
 	var targetRecord *UpdateRecord
-	for i := range c.history {
-		if c.history[i].Version == version {
+	for i := len(c.history) - 1; i >= 0; i-- {
+		if c.history[i].Version == version && c.history[i].BackupPath != "" {
 			targetRecord = &c.history[i]
 			break
 		}
 	}
 
 	if targetRecord == nil {
-		return NewClientError("BACKUP_NOT_FOUND", "Backup for version not found", nil)
+		return nil, NewClientError("BACKUP_NOT_FOUND", "Backup for version not found", nil)
 	}
 
 	// 执行回滚
 	if err := c.restoreBackup(targetRecord.BackupPath); err != nil {
-		return NewClientError("ROLLBACK_FAILED", "Failed to rollback", err)
+		return nil, NewClientError("ROLLBACK_FAILED", "Failed to rollback", err)
 	}
 
-	return nil
+	// 备份保存的是更新前的版本
+	c.clearPendingBoot()
+	targetRecord.Status = UpdateStatusRolledBack
+	c.installedVersion = targetRecord.FromVersion
+	rolledBack := *targetRecord
+	if err := c.saveState(); err != nil {
+		return nil, NewClientError("STATE_SAVE_FAILED", "Rolled back but failed to save state", err)
+	}
+
+	return &rolledBack, nil
 }
 
 // validateConfig 验证配置
@@ -286,6 +712,33 @@ func validateConfig(config *Config) error {
 		return fmt.Errorf("invalid update mode: %s, must be one of %v", config.UpdateMode, validModes)
 	}
 
+	// 验证发布渠道
+	if config.Channel == "" {
+		config.Channel = ChannelStable // 默认正式渠道
+	}
+	if _, ok := channelRanks[config.Channel]; !ok {
+		return fmt.Errorf("invalid channel: %s, must be one of %s, %s, %s", config.Channel, ChannelStable, ChannelBeta, ChannelPreview)
+	}
+
+	if config.ProgressInterval < 0 || config.ProgressStep < 0 {
+		return fmt.Errorf("progress interval and step must not be negative")
+	}
+
+	// 验证备份格式
+	if config.BackupFormat != "" {
+		if format, err := archive.ParseFormat(config.BackupFormat); err != nil || !format.IsTar() {
+			return fmt.Errorf("invalid backup format: %s, must be one of tar.gz, tar.xz, tar.zst", config.BackupFormat)
+		}
+	}
+
+	// 验证重试策略
+	if config.Retry.MaxAttempts < 0 {
+		return fmt.Errorf("invalid retry max attempts: %d", config.Retry.MaxAttempts)
+	}
+	if config.Retry.Jitter < 0 || config.Retry.Jitter > 1 {
+		return fmt.Errorf("invalid retry jitter: %v, must be between 0 and 1", config.Retry.Jitter)
+	}
+
 	return nil
 }
 
@@ -301,71 +754,30 @@ func contains(slice []string, item string) bool {
 
 // createBackup 创建当前版本的备份
 func (c *Client) createBackup() (string, error) {
-	// 获取当前执行文件路径
-	execPath, err := utils.GetExecutablePath()
-	if err != nil {
-		return "", err
-	}
-
 	// 创建备份目录
-	backupDir := filepath.Join(filepath.Dir(execPath), ".versiontrack", "backups")
+	backupDir := filepath.Join(c.stateDir(), "backups")
 	if err := utils.EnsureDir(backupDir); err != nil {
 		return "", err
 	}
 
-	// 生成备份文件名
+	format := archive.FormatTarGz
+	if c.config.BackupFormat != "" {
+		format, _ = archive.ParseFormat(c.config.BackupFormat)
+	}
+
+	// 生成备份文件名，扩展名与备份格式一致以便恢复时识别
 	timestamp := time.Now().Format("20060102_150405")
-	backupPath := filepath.Join(backupDir, fmt.Sprintf("backup_%s.tar.gz", timestamp))
+	backupPath := filepath.Join(backupDir, fmt.Sprintf("backup_%s.%s", timestamp, format))
 
-	// 创建备份
-	currentDir := filepath.Dir(execPath)
-	if err := archive.CreateTarGz(currentDir, backupPath, c.config.PreserveFiles); err != nil {
+	// 创建备份，排除状态目录以免备份嵌套以及回滚时覆盖更新历史
+	excludes := append([]string{stateDirName}, c.config.PreserveFiles...)
+	if err := archive.CreateArchive(c.installDir, backupPath, format, excludes); err != nil {
 		return "", err
 	}
 
 	return backupPath, nil
 }
 
-// applyUpdate 应用更新
-func (c *Client) applyUpdate(updateDir string) error {
-	execPath, err := utils.GetExecutablePath()
-	if err != nil {
-		return err
-	}
-
-	currentDir := filepath.Dir(execPath)
-
-	// 遍历更新文件
-	return filepath.Walk(updateDir, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
-
-		if info.IsDir() {
-			return nil
-		}
-
-		// 计算相对路径
-		relPath, err := filepath.Rel(updateDir, path)
-		if err != nil {
-			return err
-		}
-
-		targetPath := filepath.Join(currentDir, relPath)
-
-		// 检查是否是需要保护的文件
-		if c.shouldPreserveFile(relPath) {
-			// 如果目标文件已存在，跳过覆盖
-			if utils.FileExists(targetPath) {
-				return nil
-			}
-		}
-
-		// 复制文件
-		return utils.CopyFile(path, targetPath)
-	})
-}
-
 // shouldPreserveFile 检查文件是否需要保护
 func (c *Client) shouldPreserveFile(filename string) bool {
 	for _, pattern := range c.config.PreserveFiles {
@@ -381,21 +793,28 @@ func (c *Client) shouldPreserveFile(filename string) bool {
 
 // restoreBackup 恢复备份
 func (c *Client) restoreBackup(backupPath string) error {
-	execPath, err := utils.GetExecutablePath()
+	format, err := archive.DetectFormat(backupPath, "")
 	if err != nil {
 		return err
 	}
 
-	currentDir := filepath.Dir(execPath)
-
-	// 解压备份到当前目录
-	return archive.ExtractTarGz(backupPath, currentDir)
+	// 解压备份到安装目录，备份来自本机安装目录，保留原有的setuid/setgid权限位且不限制大小
+	return archive.Extract(backupPath, c.installDir, format, archive.Options{
+		AllowSetuid:  true,
+		MaxTotalSize: -1,
+		MaxFileSize:  -1,
+		MaxEntries:   -1,
+		MaxDepth:     -1,
+	})
 }
 
-// cleanupOldBackups 清理旧备份
-func (c *Client) cleanupOldBackups() {
+// cleanupOldBackups 清理旧备份并保存裁剪后的更新历史
+func (c *Client) cleanupOldBackups() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
 	if len(c.history) <= c.config.BackupCount {
-		return
+		return nil
 	}
 
 	// 删除超过保留数量的备份
@@ -406,14 +825,26 @@ func (c *Client) cleanupOldBackups() {
 		}
 	}
 
-	// 更新历史记录
+	// 更新历史记录，只保留历史中仍有记录的版本的文件清单
 	c.history = c.history[len(c.history)-c.config.BackupCount:]
+	c.pruneManifests()
+	return c.saveState()
 }
 
-// CheckForMultipleUpdates 检查多版本更新（新版本）
+// CheckForMultipleUpdates 检查多版本更新（新版本），不在AllowedVersions/MaxVersion范围内的版本会被过滤
 func (c *Client) CheckForMultipleUpdates(ctx context.Context, currentVersion string) (*UpdatesInfo, error) {
-	url := fmt.Sprintf("/api/v1/public/versions/check?platform=%s&arch=%s&currentVersion=%s",
-		c.config.Platform, c.config.Arch, currentVersion)
+	updates, err := c.fetchUpdates(ctx, currentVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	c.filterUpdates(updates)
+	return updates, nil
+}
+
+// fetchUpdates 从服务器获取多版本更新信息（未过滤）
+func (c *Client) fetchUpdates(ctx context.Context, currentVersion string) (*UpdatesInfo, error) {
+	path := c.checkPath(currentVersion)
 
 	var result struct {
 		Code    int          `json:"code"`
@@ -421,7 +852,7 @@ func (c *Client) CheckForMultipleUpdates(ctx context.Context, currentVersion str
 		Data    *UpdatesInfo `json:"data"`
 	}
 
-	if err := c.httpClient.GetWithAuth(ctx, url, c.config.APIKey, &result); err != nil {
+	if err := c.httpClient.GetWithAuth(ctx, path, c.config.APIKey, &result); err != nil {
 		return nil, NewClientError("CHECK_FAILED", "Failed to check for updates", err)
 	}
 
@@ -433,6 +864,11 @@ func (c *Client) CheckForMultipleUpdates(ctx context.Context, currentVersion str
 		return nil, NewClientError("API_ERROR", "No update data returned", nil)
 	}
 
+	// 版本权重用于服务器端排序，与版本号顺序不一致时说明版本数据有误，拒绝据此更新
+	if err := checkVersionWeights(result.Data.AvailableVersions); err != nil {
+		return nil, NewClientError("VERSION_INCONSISTENT", "Server returned inconsistent version weights", err)
+	}
+
 	return result.Data, nil
 }
 
@@ -447,71 +883,131 @@ func (c *Client) GetRecommendedUpdate(ctx context.Context, currentVersion string
 		return nil, nil
 	}
 
-	// 如果有强制更新，返回最低要求版本
+	// 如果有强制更新，返回最低要求版本（强制更新不受跳过列表影响）
 	if updates.UpdateStrategy.HasForced {
 		for _, version := range updates.AvailableVersions {
-			if version.Version == updates.UpdateStrategy.MinRequiredVersion {
+			if sameVersion(version.Version, updates.UpdateStrategy.MinRequiredVersion) {
 				return &version, nil
 			}
 		}
 	}
 
-	// 否则返回最新版本（第一个）
-	return &updates.AvailableVersions[0], nil
+	// 否则返回不在跳过列表中的最高版本
+	var candidates []*VersionInfo
+	for i := range updates.AvailableVersions {
+		if !c.isSkipped(updates.AvailableVersions[i].Version) {
+			candidates = append(candidates, &updates.AvailableVersions[i])
+		}
+	}
+	return latestVersion(candidates), nil
 }
 
 // UpdateToVersion 手动选择版本更新
+// targetVersion为版本范围约束时，更新到满足约束且不在跳过列表中的最高版本
 func (c *Client) UpdateToVersion(ctx context.Context, targetVersion string, callback ProgressCallback) error {
-	updates, err := c.CheckForMultipleUpdates(ctx, "")
+	updates, err := c.fetchUpdates(ctx, "")
 	if err != nil {
 		return err
 	}
 
-	// 查找目标版本
-	var targetVersionInfo *VersionInfo
-	for _, version := range updates.AvailableVersions {
-		if version.Version == targetVersion {
-			targetVersionInfo = &version
-			break
+	// 查找目标版本，目标为版本范围约束时选择满足约束的最高版本
+	targetVersionInfo := c.findVersion(c.allowedVersions(updates.AvailableVersions), targetVersion)
+	if targetVersionInfo == nil {
+		found := c.findVersion(updates.AvailableVersions, targetVersion)
+		if found == nil {
+			return NewClientError("VERSION_NOT_FOUND", fmt.Sprintf("Version %s not found", targetVersion), nil)
 		}
+		return NewClientError("VERSION_NOT_ALLOWED", fmt.Sprintf("Version %s is not allowed", found.Version), c.checkVersionAllowed(found))
 	}
+	targetVersion = targetVersionInfo.Version
 
-	if targetVersionInfo == nil {
-		return NewClientError("VERSION_NOT_FOUND", fmt.Sprintf("Version %s not found", targetVersion), nil)
+	// 检查是否在跳过列表中
+	if c.isSkipped(targetVersion) {
+		return NewClientError("VERSION_SKIPPED", fmt.Sprintf("Version %s is in skip list", targetVersion), nil)
 	}
 
-	// 检查是否在跳过列表中
-	for _, skipVersion := range c.config.SkipVersions {
-		if skipVersion == targetVersion {
-			return NewClientError("VERSION_SKIPPED", fmt.Sprintf("Version %s is in skip list", targetVersion), nil)
-		}
+	// 版本信息中没有文件列表时，使用响应中最新版本的文件列表
+	if len(targetVersionInfo.UpdateFiles) == 0 && targetVersion == updates.LatestVersion {
+		targetVersionInfo.UpdateFiles = updates.UpdateFiles
 	}
 
-	// 下载并更新
-	tmpDir := fmt.Sprintf("/tmp/versiontrack_update_%s", targetVersion)
-	downloadPath := filepath.Join(tmpDir, fmt.Sprintf("update_%s.tar.gz", targetVersion))
-	
-	if err := os.MkdirAll(tmpDir, 0755); err != nil {
-		return NewClientError("CREATE_DIR_FAILED", "Failed to create temp directory", err)
+	// 转换为UpdateInfo格式
+	packageFile := c.packageFile(targetVersionInfo.UpdateFiles)
+	updateInfo := &UpdateInfo{
+		HasUpdate:          true,
+		LatestVersion:      targetVersion,
+		CurrentVersion:     updates.CurrentVersion,
+		DownloadURL:        targetVersionInfo.DownloadURL,
+		FileSize:           targetVersionInfo.FileSize,
+		MD5Hash:            targetVersionInfo.FileHash,
+		FileHash:           targetVersionInfo.FileHash,
+		ReleaseNotes:       targetVersionInfo.Changelog,
+		IsForced:           targetVersionInfo.IsForced,
+		Signature:          targetVersionInfo.Signature,
+		SignatureAlgorithm: targetVersionInfo.SignatureAlgorithm,
+		UpdateFiles:        targetVersionInfo.UpdateFiles,
 	}
-	defer os.RemoveAll(tmpDir)
+	if packageFile != nil {
+		updateInfo.CompressionType = compressionType(packageFile)
+	}
+
+	start, fromVersion := time.Now(), c.fromVersion(updateInfo)
+	err = c.installVersion(ctx, targetVersionInfo, updateInfo, packageFile, callback)
+	c.reportUpdate(updateInfo, fromVersion, start, err)
+	c.flushReportsBriefly(ctx)
+	return err
+}
+
+// installVersion 下载并安装指定版本，优先使用差分补丁
+func (c *Client) installVersion(ctx context.Context, targetVersionInfo *VersionInfo, updateInfo *UpdateInfo, packageFile *UpdateFile, callback ProgressCallback) error {
+	targetVersion := targetVersionInfo.Version
+
+	// 下载目录位于状态目录下，下载中断后可在下次调用时续传
+	downloadDir := filepath.Join(c.stateDir(), "downloads")
+	c.cleanupDownloads(downloadDir, fmt.Sprintf("update_%s.", targetVersion))
+
+	// 优先使用差分补丁，补丁下载、校验或应用失败时回退到完整更新包
+	if patches := c.patchFiles(targetVersionInfo); len(patches) > 0 {
+		stagingDir, err := utils.CreateTempDir("versiontrack-patch")
+		if err != nil {
+			return NewClientError("CREATE_TEMP_FAILED", "Failed to create temp directory", err)
+		}
+		defer utils.RemoveTempDir(stagingDir)
 
+		if err := c.preparePatchedFiles(ctx, patches, downloadDir, targetVersion, stagingDir, callback); err == nil {
+			return c.installFromDir(ctx, updateInfo, stagingDir, true)
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+
+	// 下载完整更新包并更新，保留原文件扩展名以便识别格式
+	downloadName := "update_" + targetVersion
+	if packageFile != nil {
+		downloadName += archive.Extension(packageFile.FileName)
+	}
+	downloadPath := filepath.Join(downloadDir, downloadName)
 	if err := c.DownloadVersion(ctx, targetVersionInfo, downloadPath, callback); err != nil {
 		return err
 	}
+	defer utils.RemoveFile(downloadPath)
 
-	// 执行更新（转换为UpdateInfo格式）
-	updateInfo := &UpdateInfo{
-		HasUpdate:     true,
-		CurrentVersion: updates.CurrentVersion,
-		DownloadURL:   targetVersionInfo.DownloadURL,
-		FileSize:      targetVersionInfo.FileSize,
-		MD5Hash:       targetVersionInfo.FileHash,
-		ReleaseNotes:  targetVersionInfo.Changelog,
-		IsForced:      targetVersionInfo.IsForced,
+	return c.update(ctx, updateInfo, downloadPath)
+}
+
+// cleanupDownloads 清理下载目录中其他版本遗留的文件，保留以keepPrefix开头的目标版本文件以便续传
+func (c *Client) cleanupDownloads(downloadDir, keepPrefix string) {
+	entries, err := os.ReadDir(downloadDir)
+	if err != nil {
+		return
 	}
 
-	return c.Update(ctx, updateInfo, downloadPath)
+	for _, entry := range entries {
+		if !strings.HasPrefix(entry.Name(), keepPrefix) {
+			os.RemoveAll(filepath.Join(downloadDir, entry.Name()))
+		}
+	}
 }
 
 // HasForcedUpdate 检查是否有强制更新
@@ -527,7 +1023,7 @@ func (c *Client) HasForcedUpdate(ctx context.Context, currentVersion string) (*V
 
 	// 返回最低要求的强制更新版本
 	for _, version := range updates.AvailableVersions {
-		if version.IsForced && version.Version == updates.UpdateStrategy.MinRequiredVersion {
+		if version.IsForced && sameVersion(version.Version, updates.UpdateStrategy.MinRequiredVersion) {
 			return &version, nil
 		}
 	}
@@ -545,18 +1041,5 @@ func (c *Client) DownloadVersion(ctx context.Context, versionInfo *VersionInfo,
 		return NewClientError("INVALID_PARAMETER", "Download URL is empty", nil)
 	}
 
-	// 创建HTTP回调适配器
-	var httpCallback func(int64, int64)
-	if callback != nil {
-		httpCallback = func(downloaded, total int64) {
-			progress := &DownloadProgress{
-				Downloaded: downloaded,
-				Total:      total,
-				Percentage: float64(downloaded) / float64(total) * 100,
-			}
-			callback(progress)
-		}
-	}
-	
-	return c.httpClient.DownloadWithAuth(ctx, versionInfo.DownloadURL, c.config.APIKey, destPath, versionInfo.FileSize, httpCallback)
+	return c.downloadAndVerify(ctx, versionInfo.DownloadURL, destPath, versionInfo.FileSize, versionInfo.FileHash, callback)
 }
\ No newline at end of file
@@ -1,14 +1,33 @@
 package client
 
 import (
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/ed25519"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
 	"testing"
 	"time"
+
+	"github.com/CooperJiang/versiontrack-go-sdk/internal/archive"
 )
 
 func TestNewClient(t *testing.T) {
 	config := &Config{
 		ServerURL: "https://test-server.com",
-		ProjectID: "test-project",
+		APIKey:    "test-api-key",
 		Platform:  "linux",
 		Arch:      "amd64",
 	}
@@ -48,13 +67,13 @@ func TestNewClientInvalidConfig(t *testing.T) {
 		{
 			name: "missing ServerURL",
 			config: &Config{
-				ProjectID: "test-project",
-				Platform:  "linux",
-				Arch:      "amd64",
+				APIKey:   "test-api-key",
+				Platform: "linux",
+				Arch:     "amd64",
 			},
 		},
 		{
-			name: "missing ProjectID",
+			name: "missing APIKey",
 			config: &Config{
 				ServerURL: "https://test-server.com",
 				Platform:  "linux",
@@ -65,7 +84,7 @@ func TestNewClientInvalidConfig(t *testing.T) {
 			name: "invalid platform",
 			config: &Config{
 				ServerURL: "https://test-server.com",
-				ProjectID: "test-project",
+				APIKey:    "test-api-key",
 				Platform:  "invalid",
 				Arch:      "amd64",
 			},
@@ -74,7 +93,7 @@ func TestNewClientInvalidConfig(t *testing.T) {
 			name: "invalid arch",
 			config: &Config{
 				ServerURL: "https://test-server.com",
-				ProjectID: "test-project",
+				APIKey:    "test-api-key",
 				Platform:  "linux",
 				Arch:      "invalid",
 			},
@@ -91,15 +110,10 @@ func TestNewClientInvalidConfig(t *testing.T) {
 	}
 }
 
-func TestCheckForUpdates(t *testing.T) {
-	// 这里需要mock HTTP客户端，暂时跳过实际的网络请求测试
-	t.Skip("Need to implement HTTP client mocking")
-}
-
 func TestValidateConfig(t *testing.T) {
 	validConfig := &Config{
-		ServerURL: "https://test-server.com", 
-		ProjectID: "test-project",
+		ServerURL: "https://test-server.com",
+		APIKey:    "test-api-key",
 		Platform:  "linux",
 		Arch:      "amd64",
 	}
@@ -112,12 +126,227 @@ func TestValidateConfig(t *testing.T) {
 
 func TestContains(t *testing.T) {
 	slice := []string{"apple", "banana", "orange"}
-	
+
 	if !contains(slice, "apple") {
 		t.Error("Expected to find 'apple' in slice")
 	}
-	
+
 	if contains(slice, "grape") {
 		t.Error("Expected not to find 'grape' in slice")
 	}
-}
\ No newline at end of file
+}
+
+func newTestClient(t *testing.T, installDir string) *Client {
+	t.Helper()
+
+	c, err := NewClient(&Config{
+		ServerURL:      "https://test-server.com",
+		APIKey:         "test-api-key",
+		Platform:       "linux",
+		Arch:           "amd64",
+		InstallDir:     installDir,
+		DisableReports: true,
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	return c
+}
+
+func TestStatePersistence(t *testing.T) {
+	installDir := t.TempDir()
+
+	c := newTestClient(t, installDir)
+	record := UpdateRecord{
+		Version:     "1.1.0",
+		FromVersion: "1.0.0",
+		UpdatedAt:   time.Now(),
+		Status:      UpdateStatusSuccess,
+		BackupPath:  "backup_1.tar.gz",
+	}
+	if err := c.recordUpdate(record, nil); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// 模拟进程重启
+	reloaded := newTestClient(t, installDir)
+	if got := reloaded.GetInstalledVersion(); got != "1.1.0" {
+		t.Errorf("Expected installed version 1.1.0, got %q", got)
+	}
+
+	history := reloaded.GetUpdateHistory()
+	if len(history) != 1 || history[0].Version != "1.1.0" || history[0].FromVersion != "1.0.0" {
+		t.Errorf("Expected persisted history, got %+v", history)
+	}
+}
+
+func TestUpdateRefusesUnsignedPackage(t *testing.T) {
+	pub, _, _ := ed25519.GenerateKey(rand.Reader)
+
+	c, err := NewClient(&Config{
+		ServerURL:         "https://test-server.com",
+		APIKey:            "test-api-key",
+		Platform:          "linux",
+		Arch:              "amd64",
+		InstallDir:        t.TempDir(),
+		TrustedPublicKeys: []string{base64.StdEncoding.EncodeToString(pub)},
+		DisableReports:    true,
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	err = c.Update(context.Background(), &UpdateInfo{LatestVersion: "1.1.0"}, "missing.tar.gz")
+	var clientErr *ClientError
+	if !errors.As(err, &clientErr) || clientErr.Code != "SIGNATURE_INVALID" {
+		t.Fatalf("Expected SIGNATURE_INVALID, got %v", err)
+	}
+	if !errors.Is(err, ErrSignatureMissing) {
+		t.Errorf("Expected ErrSignatureMissing, got %v", err)
+	}
+}
+
+func TestDownloadVersionVerifiesHash(t *testing.T) {
+	payload := []byte("package payload")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(payload)
+	}))
+	defer server.Close()
+
+	sum := sha256.Sum256(payload)
+	md5Sum := md5.Sum(payload)
+
+	testCases := []struct {
+		name     string
+		fileHash string
+		strong   bool
+		wantErr  bool
+	}{
+		{name: "sha256 prefixed", fileHash: "sha256:" + hex.EncodeToString(sum[:])},
+		{name: "sha256 bare", fileHash: hex.EncodeToString(sum[:])},
+		{name: "md5 allowed", fileHash: hex.EncodeToString(md5Sum[:])},
+		{name: "md5 rejected when strong hash required", fileHash: hex.EncodeToString(md5Sum[:]), strong: true, wantErr: true},
+		{name: "missing hash rejected when strong hash required", strong: true, wantErr: true},
+		{name: "mismatch", fileHash: "sha256:" + strings.Repeat("0", 64), wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := newTestClient(t, t.TempDir())
+			c.config.RequireStrongHash = tc.strong
+
+			destPath := filepath.Join(t.TempDir(), "update.tar.gz")
+			err := c.DownloadVersion(context.Background(), &VersionInfo{
+				Version:     "1.1.0",
+				DownloadURL: server.URL,
+				FileHash:    tc.fileHash,
+			}, destPath, nil)
+
+			if tc.wantErr {
+				if !errors.Is(err, ErrVerificationFailed) {
+					t.Errorf("Expected ErrVerificationFailed, got %v", err)
+				}
+			} else if err != nil {
+				t.Errorf("Expected no error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestUpdateZipPackage(t *testing.T) {
+	packagePath := filepath.Join(t.TempDir(), "update.bin")
+	file, err := os.Create(packagePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw := zip.NewWriter(file)
+	w, _ := zw.Create("bin/app")
+	w.Write([]byte("new binary"))
+	zw.Close()
+	file.Close()
+
+	installDir := t.TempDir()
+	c := newTestClient(t, installDir)
+
+	info := &UpdateInfo{LatestVersion: "1.1.0", CompressionType: "zip"}
+	if err := c.Update(context.Background(), info, packagePath); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	content, err := os.ReadFile(filepath.Join(installDir, "bin", "app"))
+	if err != nil || string(content) != "new binary" {
+		t.Errorf("Expected extracted zip content, got %q (%v)", content, err)
+	}
+}
+
+func TestUpdateSingleBinary(t *testing.T) {
+	execPath, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var gzipped bytes.Buffer
+	gzw := gzip.NewWriter(&gzipped)
+	gzw.Write([]byte("new binary"))
+	gzw.Close()
+
+	testCases := []struct {
+		name            string
+		fileName        string
+		data            []byte
+		compressionType string
+	}{
+		{name: "raw", fileName: "app", data: []byte("new binary"), compressionType: "raw"},
+		{name: "gzip by extension", fileName: "app.gz", data: gzipped.Bytes()},
+		{name: "gzip by magic", fileName: "app", data: gzipped.Bytes(), compressionType: "raw"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			packagePath := filepath.Join(t.TempDir(), tc.fileName)
+			if err := os.WriteFile(packagePath, tc.data, 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			installDir := t.TempDir()
+			c := newTestClient(t, installDir)
+
+			info := &UpdateInfo{LatestVersion: "1.1.0", CompressionType: tc.compressionType}
+			if err := c.Update(context.Background(), info, packagePath); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+
+			target := filepath.Join(installDir, filepath.Base(execPath))
+			content, err := os.ReadFile(target)
+			if err != nil || string(content) != "new binary" {
+				t.Fatalf("Expected installed binary, got %q (%v)", content, err)
+			}
+			if runtime.GOOS != "windows" {
+				if stat, _ := os.Stat(target); stat.Mode().Perm() != 0755 {
+					t.Errorf("Expected mode 0755, got %v", stat.Mode().Perm())
+				}
+			}
+		})
+	}
+}
+
+func TestUpdateExtractionLimitExceeded(t *testing.T) {
+	packageDir := t.TempDir()
+	os.WriteFile(filepath.Join(packageDir, "app"), make([]byte, 64*1024), 0755)
+	packagePath := filepath.Join(t.TempDir(), "update.tar.gz")
+	if err := archive.CreateTarGz(packageDir, packagePath, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	installDir := t.TempDir()
+	c := newTestClient(t, installDir)
+	c.config.ExtractLimits.MaxFileSize = 1024
+
+	err := c.Update(context.Background(), &UpdateInfo{LatestVersion: "1.1.0"}, packagePath)
+	if !errors.Is(err, ErrExtractionFailed) || !errors.Is(err, ErrExtractionLimitExceeded) {
+		t.Fatalf("Expected extraction limit error, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(installDir, "app")); !os.IsNotExist(err) {
+		t.Error("Expected install directory to be untouched")
+	}
+}
@@ -3,6 +3,9 @@ package client
 import (
 	"errors"
 	"fmt"
+
+	"github.com/CooperJiang/versiontrack-go-sdk/internal/archive"
+	"github.com/CooperJiang/versiontrack-go-sdk/pkg/version"
 )
 
 var (
@@ -10,7 +13,13 @@ var (
 	ErrInvalidConfig = errors.New("invalid configuration")
 	
 	// ErrInvalidVersion 版本格式无效错误
-	ErrInvalidVersion = errors.New("invalid version format")
+	ErrInvalidVersion = version.ErrInvalidVersion
+
+	// ErrVersionNotAllowed 版本不在允许的范围内错误
+	ErrVersionNotAllowed = errors.New("version not allowed")
+
+	// ErrVersionInconsistent 服务器返回的版本权重与语义化版本顺序不一致错误
+	ErrVersionInconsistent = errors.New("version weight inconsistent with semantic version")
 	
 	// ErrNetworkTimeout 网络超时错误
 	ErrNetworkTimeout = errors.New("network timeout")
@@ -23,6 +32,9 @@ var (
 	
 	// ErrExtractionFailed 解压失败错误
 	ErrExtractionFailed = errors.New("extraction failed")
+
+	// ErrExtractionLimitExceeded 解压超出资源限制错误（同时匹配ErrExtractionFailed）
+	ErrExtractionLimitExceeded = archive.ErrLimitExceeded
 	
 	// ErrUpdateFailed 更新失败错误
 	ErrUpdateFailed = errors.New("update failed")
@@ -32,6 +44,21 @@ var (
 	
 	// ErrNoUpdateAvailable 无可用更新错误
 	ErrNoUpdateAvailable = errors.New("no update available")
+
+	// ErrSignatureMissing 缺少签名错误
+	ErrSignatureMissing = errors.New("signature missing")
+
+	// ErrSignatureInvalid 签名无效错误
+	ErrSignatureInvalid = errors.New("signature invalid")
+
+	// ErrHealthCheckFailed 更新后健康检查失败错误
+	ErrHealthCheckFailed = errors.New("health check failed")
+
+	// ErrBootNotConfirmed 更新后的新版本未在期限内确认运行正常错误
+	ErrBootNotConfirmed = errors.New("update not confirmed healthy")
+
+	// ErrSchedulerRunning 调度器已在运行错误
+	ErrSchedulerRunning = errors.New("scheduler already running")
 )
 
 // ClientError 客户端错误类型
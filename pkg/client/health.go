@@ -0,0 +1,155 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// maxHealthOutput 冒烟测试失败时错误信息中保留的输出长度
+const maxHealthOutput = 512
+
+// CheckHealth 执行配置的健康检查：以冒烟测试参数运行已安装的可执行文件，并轮询健康检查URL
+// 未配置任何检查时直接返回nil；检查失败时返回的错误匹配ErrHealthCheckFailed
+func (c *Client) CheckHealth(ctx context.Context) error {
+	if err := c.runSmokeTest(ctx); err != nil {
+		return err
+	}
+	return c.pollHealthURL(ctx)
+}
+
+// runSmokeTest 以HealthCheck.Args运行已安装的可执行文件，超时或退出码非0视为失败
+// 可执行文件按创建客户端时记录的路径查找，替换后运行的是新版本而不是改名后的旧文件
+func (c *Client) runSmokeTest(ctx context.Context) error {
+	check := c.config.HealthCheck
+	if len(check.Args) == 0 {
+		return nil
+	}
+
+	name, err := c.executableName()
+	if err != nil {
+		return fmt.Errorf("%w: failed to resolve executable path: %v", ErrHealthCheckFailed, err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, check.Timeout)
+	defer cancel()
+
+	var output bytes.Buffer
+	cmd := exec.CommandContext(ctx, filepath.Join(c.installDir, name), check.Args...)
+	cmd.Dir = c.installDir
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			err = fmt.Errorf("timed out after %v", check.Timeout)
+		}
+		msg := strings.TrimSpace(output.String())
+		if len(msg) > maxHealthOutput {
+			msg = msg[len(msg)-maxHealthOutput:]
+		}
+		if msg != "" {
+			return fmt.Errorf("%w: smoke test failed: %v: %s", ErrHealthCheckFailed, err, msg)
+		}
+		return fmt.Errorf("%w: smoke test failed: %v", ErrHealthCheckFailed, err)
+	}
+
+	return nil
+}
+
+// pollHealthURL 按HealthCheck.Interval轮询HealthCheck.URL，直到返回2xx或超过HealthCheck.Timeout
+func (c *Client) pollHealthURL(ctx context.Context) error {
+	check := c.config.HealthCheck
+	if check.URL == "" {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, check.Timeout)
+	defer cancel()
+
+	client := &http.Client{Timeout: check.Interval}
+	var lastErr error
+	for {
+		lastErr = probeURL(ctx, client, check.URL)
+		if lastErr == nil {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return fmt.Errorf("%w: %s not healthy after %v: %v", ErrHealthCheckFailed, check.URL, check.Timeout, lastErr)
+			}
+			return ctx.Err()
+		case <-time.After(check.Interval):
+		}
+	}
+}
+
+// probeURL 请求一次健康检查URL，返回2xx视为健康
+func probeURL(ctx context.Context, client *http.Client, url string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status: %s", resp.Status)
+	}
+	return nil
+}
+
+// rollbackUnhealthy 健康检查失败时恢复当前安装版本的更新前备份，
+// 将对应的更新记录标记为rolled_back并记录失败原因，返回标记后的记录
+func (c *Client) rollbackUnhealthy(cause error) (*UpdateRecord, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var record *UpdateRecord
+	for i := len(c.history) - 1; i >= 0; i-- {
+		if c.history[i].Version == c.installedVersion && c.history[i].Status == UpdateStatusSuccess {
+			record = &c.history[i]
+			break
+		}
+	}
+	if record == nil || record.BackupPath == "" {
+		return nil, NewClientError("BACKUP_NOT_FOUND", "Backup for version not found", nil)
+	}
+
+	if err := c.restoreBackup(record.BackupPath); err != nil {
+		return nil, NewClientError("ROLLBACK_FAILED", "Failed to rollback", err)
+	}
+	c.clearPendingBoot()
+
+	record.Status = UpdateStatusRolledBack
+	record.Error = cause.Error()
+	c.installedVersion = record.FromVersion
+	rolledBack := *record
+	if err := c.saveState(); err != nil {
+		return nil, NewClientError("STATE_SAVE_FAILED", "Rolled back but failed to save state", err)
+	}
+
+	return &rolledBack, nil
+}
+
+// healthCheckFailed 回滚未通过健康检查的更新，返回被回滚的更新记录和HEALTH_CHECK_FAILED错误
+func (c *Client) healthCheckFailed(cause error) (*UpdateRecord, error) {
+	record, err := c.rollbackUnhealthy(cause)
+	if err != nil {
+		return nil, NewClientError("HEALTH_CHECK_AND_ROLLBACK_FAILED",
+			fmt.Sprintf("Health check failed: %v, Rollback also failed: %v", cause, err), cause)
+	}
+	return record, NewClientError("HEALTH_CHECK_FAILED", "Health check failed, rolled back successfully", cause)
+}
@@ -0,0 +1,145 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestUpdateHealthCheckRollsBack(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("smoke test uses a shell script")
+	}
+
+	execPath, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	installDir := t.TempDir()
+	target := filepath.Join(installDir, filepath.Base(execPath))
+	if err := os.WriteFile(target, []byte("#!/bin/sh\nexit 0\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	packagePath := filepath.Join(t.TempDir(), "app")
+	if err := os.WriteFile(packagePath, []byte("#!/bin/sh\necho boom >&2\nexit 1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := NewClient(&Config{
+		ServerURL:      "https://test-server.com",
+		APIKey:         "test-api-key",
+		Platform:       "linux",
+		Arch:           "amd64",
+		InstallDir:     installDir,
+		CurrentVersion: "1.0.0",
+		HealthCheck:    HealthCheck{Args: []string{"--smoke-test"}, Timeout: 5 * time.Second},
+		DisableReports: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	info := &UpdateInfo{LatestVersion: "1.1.0", CompressionType: "raw"}
+	err = c.Update(context.Background(), info, packagePath)
+	var clientErr *ClientError
+	if !errors.As(err, &clientErr) || clientErr.Code != "HEALTH_CHECK_FAILED" {
+		t.Fatalf("Expected HEALTH_CHECK_FAILED, got %v", err)
+	}
+	if !errors.Is(err, ErrHealthCheckFailed) {
+		t.Errorf("Expected error to match ErrHealthCheckFailed, got %v", err)
+	}
+
+	assertFileContent(t, target, "#!/bin/sh\nexit 0\n")
+	if version := c.GetInstalledVersion(); version != "1.0.0" {
+		t.Errorf("Expected installed version 1.0.0, got %q", version)
+	}
+	history := c.GetUpdateHistory()
+	if len(history) != 1 || history[0].Status != UpdateStatusRolledBack || !strings.Contains(history[0].Error, "boom") {
+		t.Errorf("Expected rolled back record with failure reason, got %+v", history)
+	}
+}
+
+// smokeScript 冒烟测试通过时在工作目录中留下标记文件的新版本
+const smokeScript = "#!/bin/sh\n[ \"$1\" = --smoke-test ] && touch smoke-passed\n"
+
+func TestUpdateHealthCheckRunsSwappedExecutable(t *testing.T) {
+	installDir := t.TempDir()
+	runInstalledCopy(t, installDir, "TestSmokeTestHelper")
+
+	assertFileContent(t, filepath.Join(installDir, "app"), smokeScript)
+	if _, err := os.Stat(filepath.Join(installDir, "smoke-passed")); err != nil {
+		t.Errorf("Expected smoke test to run the new executable, got %v", err)
+	}
+	if version := newTestClient(t, installDir).GetInstalledVersion(); version != "1.1.0" {
+		t.Errorf("Expected installed version 1.1.0, got %q", version)
+	}
+}
+
+// TestSmokeTestHelper 由TestUpdateHealthCheckRunsSwappedExecutable在子进程中运行，
+// 更新正在运行的可执行文件并对新版本执行冒烟测试
+func TestSmokeTestHelper(t *testing.T) {
+	installDir := os.Getenv(selfUpdateDirEnv)
+	if installDir == "" {
+		t.Skip("helper process for TestUpdateHealthCheckRunsSwappedExecutable")
+	}
+
+	c, err := NewClient(&Config{
+		ServerURL:      "https://test-server.com",
+		APIKey:         "test-api-key",
+		Platform:       "linux",
+		Arch:           "amd64",
+		InstallDir:     installDir,
+		CurrentVersion: "1.0.0",
+		HealthCheck:    HealthCheck{Args: []string{"--smoke-test"}, Timeout: 5 * time.Second},
+		DisableReports: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	packagePath := filepath.Join(t.TempDir(), "app")
+	writeTestFile(t, packagePath, smokeScript)
+	info := &UpdateInfo{LatestVersion: "1.1.0", CompressionType: "raw"}
+	if err := c.Update(context.Background(), info, packagePath); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+}
+
+func TestCheckHealthPollsURL(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&requests, 1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	c := newTestClient(t, t.TempDir())
+	c.config.HealthCheck = HealthCheck{URL: server.URL, Timeout: 5 * time.Second, Interval: 10 * time.Millisecond}
+	if err := c.CheckHealth(context.Background()); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if got := atomic.LoadInt32(&requests); got != 3 {
+		t.Errorf("Expected 3 requests, got %d", got)
+	}
+
+	unhealthy := httptest.NewServer(http.NotFoundHandler())
+	defer unhealthy.Close()
+
+	c.config.HealthCheck = HealthCheck{URL: unhealthy.URL, Timeout: 100 * time.Millisecond, Interval: 10 * time.Millisecond}
+	if err := c.CheckHealth(context.Background()); !errors.Is(err, ErrHealthCheckFailed) {
+		t.Errorf("Expected ErrHealthCheckFailed, got %v", err)
+	}
+}
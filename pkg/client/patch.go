@@ -0,0 +1,115 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/CooperJiang/versiontrack-go-sdk/internal/archive"
+	"github.com/CooperJiang/versiontrack-go-sdk/internal/patch"
+	"github.com/CooperJiang/versiontrack-go-sdk/internal/utils"
+)
+
+// patchFiles 返回可从当前已安装版本直接升级到目标版本的差分补丁
+// 只有所有补丁都匹配当前平台、架构和已安装版本时才可使用
+func (c *Client) patchFiles(versionInfo *VersionInfo) []UpdateFile {
+	installed := c.GetInstalledVersion()
+	if installed == "" {
+		return nil
+	}
+
+	var patches []UpdateFile
+	for _, file := range versionInfo.UpdateFiles {
+		if file.FileType != FileTypePatch {
+			continue
+		}
+		if c.matchingFile([]UpdateFile{file}) == nil {
+			continue
+		}
+		if file.BaseVersion != installed {
+			continue
+		}
+		patches = append(patches, file)
+	}
+
+	return patches
+}
+
+// preparePatchedFiles 下载差分补丁并应用到已安装的文件，将生成的新文件写入stagingDir
+// 每个补丁都会校验补丁文件本身的哈希和签名，以及打补丁后目标文件的哈希
+func (c *Client) preparePatchedFiles(ctx context.Context, patches []UpdateFile, downloadDir, targetVersion, stagingDir string, callback ProgressCallback) error {
+	for i, file := range patches {
+		relPath := filepath.Clean(filepath.FromSlash(file.FilePath))
+		if file.FilePath == "" || !filepath.IsLocal(relPath) {
+			return fmt.Errorf("invalid patch target path: %q", file.FilePath)
+		}
+		if file.TargetHash == "" {
+			return fmt.Errorf("patch for %s has no target hash", file.FilePath)
+		}
+
+		// 下载补丁文件
+		patchPath := filepath.Join(downloadDir, fmt.Sprintf("update_%s.patch%d", targetVersion, i))
+		if err := c.downloadAndVerify(ctx, file.DownloadURL, patchPath, file.FileSize, file.FileHash, callback); err != nil {
+			return err
+		}
+		err := c.applyPatchFile(file, patchPath, relPath, stagingDir)
+		utils.RemoveFile(patchPath)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// maxPatchedFileSize 返回打补丁后文件的大小上限，与解压时的单文件大小限制一致，-1表示不限制
+func (c *Client) maxPatchedFileSize() int64 {
+	switch limit := c.config.ExtractLimits.MaxFileSize; {
+	case limit == 0:
+		return archive.DefaultMaxFileSize
+	case limit < 0:
+		return -1
+	default:
+		return limit
+	}
+}
+
+// applyPatchFile 将单个补丁应用到安装目录下的relPath，结果写入stagingDir
+func (c *Client) applyPatchFile(file UpdateFile, patchPath, relPath, stagingDir string) error {
+	if err := c.verifySignature(patchPath, file.Signature, file.SignatureAlgorithm); err != nil {
+		return err
+	}
+
+	sourcePath := filepath.Join(c.installDir, relPath)
+	sourceInfo, err := os.Stat(sourcePath)
+	if err != nil {
+		return fmt.Errorf("failed to stat patch source: %w", err)
+	}
+
+	oldData, err := os.ReadFile(sourcePath)
+	if err != nil {
+		return fmt.Errorf("failed to read patch source: %w", err)
+	}
+
+	patchData, err := os.ReadFile(patchPath)
+	if err != nil {
+		return fmt.Errorf("failed to read patch file: %w", err)
+	}
+
+	newData, err := patch.Apply(oldData, patchData, c.maxPatchedFileSize())
+	if err != nil {
+		return fmt.Errorf("failed to apply patch to %s: %w", file.FilePath, err)
+	}
+
+	targetPath := filepath.Join(stagingDir, relPath)
+	if err := utils.EnsureDir(filepath.Dir(targetPath)); err != nil {
+		return err
+	}
+	if err := os.WriteFile(targetPath, newData, sourceInfo.Mode().Perm()); err != nil {
+		return fmt.Errorf("failed to write patched file: %w", err)
+	}
+
+	// 校验打补丁后的文件，确保与完整更新包中的文件一致
+	return c.verifyHash(targetPath, file.TargetHash)
+}
@@ -0,0 +1,141 @@
+package client
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/CooperJiang/versiontrack-go-sdk/internal/archive"
+)
+
+// testPatchPath 将oldScript升级为newScript的BSDIFF40补丁，与internal/patch的测试共用
+var testPatchPath = filepath.Join("..", "..", "internal", "patch", "testdata", "upgrade.bsdiff")
+
+const (
+	oldScript = "#!/bin/sh\necho version 1.0.0\n"
+	newScript = "#!/bin/sh\necho version 1.1.0\nexit 0\n"
+)
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// newPatchServer 启动提供1.0.0到1.1.0差分补丁和完整更新包的测试服务器
+func newPatchServer(t *testing.T, targetHash string, fullDownloads *int32) *httptest.Server {
+	t.Helper()
+
+	patchData, err := os.ReadFile(testPatchPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 构建完整更新包
+	packageDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(packageDir, "app.sh"), []byte(newScript), 0755); err != nil {
+		t.Fatal(err)
+	}
+	packagePath := filepath.Join(t.TempDir(), "package.tar.gz")
+	if err := archive.CreateTarGz(packageDir, packagePath, nil); err != nil {
+		t.Fatal(err)
+	}
+	packageData, err := os.ReadFile(packagePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/check"):
+			version := VersionInfo{
+				Version:     "1.1.0",
+				DownloadURL: server.URL + "/full",
+				FileSize:    int64(len(packageData)),
+				FileHash:    sha256Hex(packageData),
+				UpdateFiles: []UpdateFile{{
+					FilePath:    "app.sh",
+					FileType:    FileTypePatch,
+					Platform:    "linux",
+					Arch:        "amd64",
+					BaseVersion: "1.0.0",
+					DownloadURL: server.URL + "/patch",
+					FileSize:    int64(len(patchData)),
+					FileHash:    sha256Hex(patchData),
+					TargetHash:  targetHash,
+				}},
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"code": 200,
+				"data": UpdatesInfo{HasUpdate: true, LatestVersion: "1.1.0", AvailableVersions: []VersionInfo{version}},
+			})
+		case r.URL.Path == "/patch":
+			w.Write(patchData)
+		case r.URL.Path == "/full":
+			atomic.AddInt32(fullDownloads, 1)
+			w.Write(packageData)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestUpdateToVersionWithPatch(t *testing.T) {
+	testCases := []struct {
+		name          string
+		targetHash    string
+		fullDownloads int32
+	}{
+		{name: "patch applied", targetHash: sha256Hex([]byte(newScript))},
+		{name: "falls back to full package on hash mismatch", targetHash: "sha256:" + strings.Repeat("0", 64), fullDownloads: 1},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var fullDownloads int32
+			server := newPatchServer(t, tc.targetHash, &fullDownloads)
+
+			installDir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(installDir, "app.sh"), []byte(oldScript), 0755); err != nil {
+				t.Fatal(err)
+			}
+
+			c, err := NewClient(&Config{
+				ServerURL:      server.URL,
+				APIKey:         "test-api-key",
+				Platform:       "linux",
+				Arch:           "amd64",
+				InstallDir:     installDir,
+				CurrentVersion: "1.0.0",
+			})
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+
+			if err := c.UpdateToVersion(context.Background(), "1.1.0", nil); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+
+			content, _ := os.ReadFile(filepath.Join(installDir, "app.sh"))
+			if string(content) != newScript {
+				t.Errorf("Expected updated script, got %q", string(content))
+			}
+			if fullDownloads != tc.fullDownloads {
+				t.Errorf("Expected %d full package downloads, got %d", tc.fullDownloads, fullDownloads)
+			}
+			if version := c.GetInstalledVersion(); version != "1.1.0" {
+				t.Errorf("Expected installed version 1.1.0, got %s", version)
+			}
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package client
+
+import (
+	"fmt"
+
+	"github.com/CooperJiang/versiontrack-go-sdk/pkg/version"
+)
+
+// versionPolicy 允许安装的版本范围
+type versionPolicy struct {
+	allowed    *version.Constraints
+	maxVersion *version.Version
+}
+
+// parseVersionPolicy 解析Config.AllowedVersions和Config.MaxVersion
+func parseVersionPolicy(config *Config) (versionPolicy, error) {
+	var policy versionPolicy
+
+	if config.AllowedVersions != "" {
+		constraints, err := version.ParseConstraints(config.AllowedVersions)
+		if err != nil {
+			return versionPolicy{}, fmt.Errorf("invalid allowed versions: %w", err)
+		}
+		policy.allowed = &constraints
+	}
+	if config.MaxVersion != "" {
+		maxVersion, err := version.Parse(config.MaxVersion)
+		if err != nil {
+			return versionPolicy{}, fmt.Errorf("invalid max version: %w", err)
+		}
+		policy.maxVersion = &maxVersion
+	}
+
+	return policy, nil
+}
+
+// checkVersionAllowed 检查版本是否属于客户端所在渠道且在允许的范围内，不允许时返回匹配ErrVersionNotAllowed的错误
+func (c *Client) checkVersionAllowed(v *VersionInfo) error {
+	if !c.channelIncludes(v) {
+		return fmt.Errorf("%w: %s is in %s channel, client is on %s channel",
+			ErrVersionNotAllowed, v.Version, versionChannel(v), c.config.Channel)
+	}
+	return c.checkVersionRange(v.Version)
+}
+
+// checkVersionRange 检查版本是否满足AllowedVersions和MaxVersion
+// 配置了版本范围时，无法按语义化版本解析的版本一律不允许
+func (c *Client) checkVersionRange(v string) error {
+	policy := c.policy
+	if policy.allowed == nil && policy.maxVersion == nil {
+		return nil
+	}
+
+	parsed, err := version.Parse(v)
+	if err != nil {
+		return fmt.Errorf("%w: %s is not a semantic version", ErrVersionNotAllowed, v)
+	}
+	if policy.allowed != nil && !policy.allowed.Check(parsed) {
+		return fmt.Errorf("%w: %s does not satisfy %q", ErrVersionNotAllowed, v, policy.allowed.String())
+	}
+	if policy.maxVersion != nil && parsed.Compare(*policy.maxVersion) > 0 {
+		return fmt.Errorf("%w: %s is newer than max version %s", ErrVersionNotAllowed, v, policy.maxVersion.String())
+	}
+	return nil
+}
+
+// allowedVersions 返回允许安装的版本
+func (c *Client) allowedVersions(versions []VersionInfo) []VersionInfo {
+	allowed := make([]VersionInfo, 0, len(versions))
+	for i := range versions {
+		if c.checkVersionAllowed(&versions[i]) == nil {
+			allowed = append(allowed, versions[i])
+		}
+	}
+	return allowed
+}
+
+// filterUpdates 过滤掉不属于客户端所在渠道、不在允许范围内或本安装实例尚未进入灰度范围的版本
+// 最新版本被过滤时，LatestVersion改为剩余版本中的最高版本，并清空属于原最新版本的UpdateFiles
+func (c *Client) filterUpdates(updates *UpdatesInfo) {
+	latestAllowed := updates.LatestVersion == "" || c.checkVersionRange(updates.LatestVersion) == nil
+	offered := make([]VersionInfo, 0, len(updates.AvailableVersions))
+	for i := range updates.AvailableVersions {
+		v := &updates.AvailableVersions[i]
+		if c.checkVersionAllowed(v) == nil && c.inRollout(v) {
+			offered = append(offered, *v)
+		} else if sameVersion(v.Version, updates.LatestVersion) {
+			latestAllowed = false
+		}
+	}
+
+	updates.AvailableVersions = offered
+	if latestAllowed {
+		return
+	}
+
+	candidates := make([]*VersionInfo, 0, len(updates.AvailableVersions))
+	for i := range updates.AvailableVersions {
+		candidates = append(candidates, &updates.AvailableVersions[i])
+	}
+	updates.UpdateFiles = nil
+	updates.LatestVersion = ""
+	if latest := latestVersion(candidates); latest != nil {
+		updates.LatestVersion = latest.Version
+	}
+	updates.HasUpdate = len(updates.AvailableVersions) > 0
+}
@@ -0,0 +1,70 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func newPolicyClient(t *testing.T, serverURL, allowed, maxVersion string) (*Client, error) {
+	t.Helper()
+
+	return NewClient(&Config{
+		ServerURL:       serverURL,
+		APIKey:          "test-api-key",
+		Platform:        "linux",
+		Arch:            "amd64",
+		InstallDir:      t.TempDir(),
+		AllowedVersions: allowed,
+		MaxVersion:      maxVersion,
+	})
+}
+
+func TestVersionPolicyFiltersUpdates(t *testing.T) {
+	server := newVersionsServer(t, []VersionInfo{
+		{Version: "3.0.0"},
+		{Version: "2.5.0"},
+		{Version: "2.4.0"},
+		{Version: "1.9.0"},
+	})
+	c, err := newPolicyClient(t, server.URL, "^2", "2.4.9")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	updates, err := c.CheckForMultipleUpdates(context.Background(), "1.9.0")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(updates.AvailableVersions) != 1 || updates.AvailableVersions[0].Version != "2.4.0" {
+		t.Errorf("Expected only 2.4.0 to be available, got %+v", updates.AvailableVersions)
+	}
+	if !updates.HasUpdate || updates.LatestVersion != "2.4.0" {
+		t.Errorf("Expected latest allowed version 2.4.0, got %q (hasUpdate=%v)", updates.LatestVersion, updates.HasUpdate)
+	}
+
+	recommended, err := c.GetRecommendedUpdate(context.Background(), "1.9.0")
+	if err != nil || recommended == nil || recommended.Version != "2.4.0" {
+		t.Errorf("Expected recommended version 2.4.0, got %+v (%v)", recommended, err)
+	}
+
+	for _, target := range []string{"3.0.0", "2.5.0", ">=3"} {
+		err = c.UpdateToVersion(context.Background(), target, nil)
+		var clientErr *ClientError
+		if !errors.As(err, &clientErr) || clientErr.Code != "VERSION_NOT_ALLOWED" {
+			t.Errorf("UpdateToVersion(%q): expected VERSION_NOT_ALLOWED, got %v", target, err)
+		}
+		if !errors.Is(err, ErrVersionNotAllowed) {
+			t.Errorf("UpdateToVersion(%q): expected error to match ErrVersionNotAllowed, got %v", target, err)
+		}
+	}
+}
+
+func TestVersionPolicyInvalidConfig(t *testing.T) {
+	if _, err := newPolicyClient(t, "https://test-server.com", ">=abc", ""); err == nil {
+		t.Error("Expected invalid AllowedVersions to be rejected")
+	}
+	if _, err := newPolicyClient(t, "https://test-server.com", "", "2.x"); err == nil {
+		t.Error("Expected invalid MaxVersion to be rejected")
+	}
+}
@@ -0,0 +1,142 @@
+package client
+
+import (
+	"time"
+)
+
+const (
+	// defaultProgressInterval 默认进度回调间隔
+	defaultProgressInterval = 200 * time.Millisecond
+	// speedSampleWindow 速度采样窗口
+	speedSampleWindow = 500 * time.Millisecond
+	// speedSmoothing 速度指数平滑系数，越大越偏向最近的采样
+	speedSmoothing = 0.3
+)
+
+// progressTracker 计算下载速度和剩余时间，并对进度回调进行节流
+type progressTracker struct {
+	callback ProgressCallback
+	interval time.Duration
+	step     float64
+
+	start       time.Time
+	sampleTime  time.Time
+	sampleBytes int64
+	speed       float64
+
+	lastEmit    time.Time
+	lastPercent float64
+	emitted     bool
+	pending     bool
+	latest      DownloadProgress
+}
+
+// newProgressTracker 创建进度追踪器，callback为nil时返回nil
+func (c *Client) newProgressTracker(callback ProgressCallback) *progressTracker {
+	if callback == nil {
+		return nil
+	}
+
+	interval := c.config.ProgressInterval
+	if interval == 0 && c.config.ProgressStep <= 0 {
+		interval = defaultProgressInterval
+	}
+
+	return &progressTracker{
+		callback: callback,
+		interval: interval,
+		step:     c.config.ProgressStep,
+	}
+}
+
+// update 处理HTTP层上报的下载字节数
+func (t *progressTracker) update(downloaded, total int64) {
+	now := time.Now()
+	if t.start.IsZero() {
+		t.start = now
+		t.sampleTime = now
+		t.sampleBytes = downloaded
+	}
+
+	// 重试从头下载时字节数会回退，重新开始采样
+	if downloaded < t.sampleBytes {
+		t.sampleTime = now
+		t.sampleBytes = downloaded
+	}
+
+	// 按采样窗口计算瞬时速度，并做指数平滑
+	if dt := now.Sub(t.sampleTime); dt >= speedSampleWindow {
+		rate := float64(downloaded-t.sampleBytes) / dt.Seconds()
+		if t.speed == 0 {
+			t.speed = rate
+		} else {
+			t.speed = speedSmoothing*rate + (1-speedSmoothing)*t.speed
+		}
+		t.sampleTime = now
+		t.sampleBytes = downloaded
+	}
+
+	t.latest = DownloadProgress{
+		Downloaded: downloaded,
+		Total:      total,
+		Speed:      int64(t.speed),
+		Elapsed:    now.Sub(t.start),
+	}
+	if total > 0 {
+		t.latest.Percentage = float64(downloaded) / float64(total) * 100
+		if t.speed > 0 && downloaded < total {
+			t.latest.Remaining = time.Duration(float64(total-downloaded) / t.speed * float64(time.Second))
+		}
+	}
+
+	if t.shouldEmit(now, downloaded, total) {
+		t.emit(now)
+	} else {
+		t.pending = true
+	}
+}
+
+// shouldEmit 判断是否需要触发回调：完成时、距上次回调超过间隔、或进度增长超过步长
+func (t *progressTracker) shouldEmit(now time.Time, downloaded, total int64) bool {
+	if !t.emitted {
+		return true
+	}
+	if total > 0 && downloaded >= total {
+		return true
+	}
+	if t.interval > 0 && now.Sub(t.lastEmit) >= t.interval {
+		return true
+	}
+	if t.step > 0 && total > 0 && t.latest.Percentage-t.lastPercent >= t.step {
+		return true
+	}
+	return false
+}
+
+// emit 触发回调
+func (t *progressTracker) emit(now time.Time) {
+	t.emitted = true
+	t.pending = false
+	t.lastEmit = now
+	t.lastPercent = t.latest.Percentage
+
+	progress := t.latest
+	t.callback(&progress)
+}
+
+// finish 下载结束时补发被节流的最后一次进度
+func (t *progressTracker) finish() {
+	if t == nil || !t.pending {
+		return
+	}
+	t.latest.Remaining = 0
+	t.emit(time.Now())
+}
+
+// httpCallback 返回HTTP层使用的进度回调，追踪器为nil时返回nil
+func (t *progressTracker) httpCallback() func(downloaded, total int64) {
+	if t == nil {
+		return nil
+	}
+	return t.update
+}
@@ -0,0 +1,84 @@
+package client
+
+import (
+	"testing"
+	"time"
+)
+
+func TestProgressTrackerThrottlesCallbacks(t *testing.T) {
+	c := newTestClient(t, t.TempDir())
+	c.config.ProgressInterval = time.Hour
+
+	var calls []DownloadProgress
+	tracker := c.newProgressTracker(func(p *DownloadProgress) {
+		calls = append(calls, *p)
+	})
+
+	for downloaded := int64(1); downloaded <= 1000; downloaded++ {
+		tracker.update(downloaded, 1000)
+	}
+	tracker.finish()
+
+	// 首次回调和完成时的回调
+	if len(calls) != 2 {
+		t.Fatalf("Expected 2 callbacks, got %d", len(calls))
+	}
+	if last := calls[len(calls)-1]; last.Percentage != 100 || last.Remaining != 0 {
+		t.Errorf("Expected final callback at 100%%, got %+v", last)
+	}
+}
+
+func TestProgressTrackerPercentStep(t *testing.T) {
+	c := newTestClient(t, t.TempDir())
+	c.config.ProgressStep = 10
+
+	var calls int
+	tracker := c.newProgressTracker(func(p *DownloadProgress) {
+		calls++
+	})
+
+	for downloaded := int64(1); downloaded <= 1000; downloaded++ {
+		tracker.update(downloaded, 1000)
+	}
+	tracker.finish()
+
+	// 首次回调 + 每10%一次
+	if calls != 11 {
+		t.Errorf("Expected 11 callbacks, got %d", calls)
+	}
+}
+
+func TestProgressTrackerUnknownTotal(t *testing.T) {
+	c := newTestClient(t, t.TempDir())
+	c.config.ProgressInterval = time.Hour
+
+	var last DownloadProgress
+	tracker := c.newProgressTracker(func(p *DownloadProgress) {
+		last = *p
+	})
+
+	tracker.update(100, -1)
+	tracker.update(200, -1)
+	tracker.finish()
+
+	if last.Downloaded != 200 || last.Percentage != 0 || last.Remaining != 0 {
+		t.Errorf("Expected final progress without percentage, got %+v", last)
+	}
+}
+
+func TestProgressTrackerSpeed(t *testing.T) {
+	c := newTestClient(t, t.TempDir())
+
+	var last DownloadProgress
+	tracker := c.newProgressTracker(func(p *DownloadProgress) {
+		last = *p
+	})
+
+	tracker.update(0, 10000)
+	time.Sleep(speedSampleWindow + 50*time.Millisecond)
+	tracker.update(5000, 10000)
+
+	if last.Speed <= 0 || last.Remaining <= 0 || last.Elapsed < speedSampleWindow {
+		t.Errorf("Expected speed, ETA and elapsed to be computed, got %+v", last)
+	}
+}
@@ -0,0 +1,237 @@
+package client
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/CooperJiang/versiontrack-go-sdk/internal/http"
+	"github.com/CooperJiang/versiontrack-go-sdk/internal/utils"
+)
+
+const (
+	// reportsDirName 待上报的更新结果目录名称（位于状态目录下）
+	reportsDirName = "reports"
+	// reportsPath 更新结果上报接口
+	reportsPath = "/api/v1/public/versions/reports"
+	// maxQueuedReports 最多保留的待上报结果数量，超出时丢弃最早的结果
+	maxQueuedReports = 100
+)
+
+// reportFlushTimeout 更新或回滚后随即上报结果的时间上限（测试时可替换），
+// 超时未发送的结果留在队列中，由调度器或下次FlushReports上报
+var reportFlushTimeout = 3 * time.Second
+
+// FlushReports 上报磁盘队列中的更新结果，上报成功或被服务器拒绝（4xx）的结果会从队列中删除
+// 遇到网络错误或服务器错误时停止并返回错误，剩余结果留待下次上报
+func (c *Client) FlushReports(ctx context.Context) error {
+	if c.config.DisableReports {
+		return nil
+	}
+
+	c.reportMu.Lock()
+	defer c.reportMu.Unlock()
+
+	paths, err := c.queuedReports()
+	if err != nil {
+		return NewClientError("REPORT_FAILED", "Failed to read queued reports", err)
+	}
+
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		var report InstallReport
+		if err := json.Unmarshal(data, &report); err != nil {
+			os.Remove(path)
+			continue
+		}
+
+		if err := c.sendReport(ctx, &report); err != nil {
+			if !rejectedReport(err) {
+				return NewClientError("REPORT_FAILED", "Failed to send install report", err)
+			}
+		}
+		os.Remove(path)
+	}
+	return nil
+}
+
+// flushReportsBriefly 在reportFlushTimeout内上报队列中的结果，避免上报接口缓慢时延迟更新或回滚的返回
+func (c *Client) flushReportsBriefly(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, reportFlushTimeout)
+	defer cancel()
+
+	c.FlushReports(ctx)
+}
+
+// reportUpdate 记录一次更新的结果，err为nil表示成功，HEALTH_CHECK_FAILED表示更新后已回滚
+func (c *Client) reportUpdate(info *UpdateInfo, fromVersion string, start time.Time, err error) {
+	status := UpdateStatusSuccess
+	if err != nil {
+		status = UpdateStatusFailed
+		var clientErr *ClientError
+		if errors.As(err, &clientErr) && clientErr.Code == "HEALTH_CHECK_FAILED" {
+			status = UpdateStatusRolledBack
+		}
+	}
+
+	c.report(InstallReport{
+		FromVersion: fromVersion,
+		ToVersion:   info.LatestVersion,
+		Status:      status,
+		DurationMs:  time.Since(start).Milliseconds(),
+	}, err)
+}
+
+// reportRollback 记录已回滚的更新
+func (c *Client) reportRollback(record *UpdateRecord, start time.Time, cause error) {
+	c.report(InstallReport{
+		FromVersion: record.FromVersion,
+		ToVersion:   record.Version,
+		Status:      UpdateStatusRolledBack,
+		DurationMs:  time.Since(start).Milliseconds(),
+	}, cause)
+}
+
+// report 补全公共字段后将结果写入磁盘队列，由调用方随后调用FlushReports上报
+// 写入失败不影响更新本身
+func (c *Client) report(report InstallReport, err error) {
+	if c.config.DisableReports {
+		return
+	}
+
+	report.InstallationID = c.installationID
+	report.Platform = c.config.Platform
+	report.Arch = c.config.Arch
+	report.Channel = c.config.Channel
+	report.ReportedAt = time.Now()
+	if err != nil {
+		report.ErrorCode = errorCode(err)
+		report.ErrorMessage = err.Error()
+	}
+
+	c.queueReport(&report)
+}
+
+// errorCode 返回错误对应的错误码
+func errorCode(err error) string {
+	var clientErr *ClientError
+	switch {
+	case errors.As(err, &clientErr):
+		return clientErr.Code
+	case errors.Is(err, ErrBootNotConfirmed):
+		return "BOOT_NOT_CONFIRMED"
+	case errors.Is(err, context.Canceled):
+		return "CANCELED"
+	case errors.Is(err, context.DeadlineExceeded):
+		return "TIMEOUT"
+	}
+	return "UNKNOWN"
+}
+
+// sendReport 上报单条更新结果
+func (c *Client) sendReport(ctx context.Context, report *InstallReport) error {
+	var result struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	}
+	if err := c.httpClient.PostWithAuth(ctx, reportsPath, c.config.APIKey, report, &result); err != nil {
+		return err
+	}
+	if result.Code != 0 && result.Code != 200 {
+		return &reportRejectedError{Code: result.Code, Message: result.Message}
+	}
+	return nil
+}
+
+// reportRejectedError 服务器以HTTP 200响应但在响应体中拒绝上报结果的错误
+type reportRejectedError struct {
+	Code    int
+	Message string
+}
+
+// Error 实现error接口
+func (e *reportRejectedError) Error() string {
+	return fmt.Sprintf("server rejected install report (code %d): %s", e.Code, e.Message)
+}
+
+// rejectedReport 检查上报错误是否表示服务器拒绝了该结果（重试也不会成功）
+func rejectedReport(err error) bool {
+	var rejectedErr *reportRejectedError
+	if errors.As(err, &rejectedErr) {
+		return true
+	}
+	var statusErr *http.StatusError
+	if !errors.As(err, &statusErr) {
+		return false
+	}
+	code := statusErr.StatusCode
+	return code >= 400 && code < 500 && code != 408 && code != 429
+}
+
+// reportsDir 返回待上报结果目录路径
+func (c *Client) reportsDir() string {
+	return filepath.Join(c.stateDir(), reportsDirName)
+}
+
+// queueReport 将更新结果原子写入磁盘队列，队列已满时丢弃最早的结果
+func (c *Client) queueReport(report *InstallReport) error {
+	c.reportMu.Lock()
+	defer c.reportMu.Unlock()
+
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return err
+	}
+	report.ID = hex.EncodeToString(buf)
+
+	data, err := json.Marshal(report)
+	if err != nil {
+		return err
+	}
+	// 文件名以纳秒时间戳开头，按名称排序即为生成顺序
+	name := fmt.Sprintf("%020d-%s.json", report.ReportedAt.UnixNano(), report.ID)
+	if err := utils.WriteFileAtomic(filepath.Join(c.reportsDir(), name), data, 0644); err != nil {
+		return err
+	}
+
+	paths, err := c.queuedReports()
+	if err != nil {
+		return nil
+	}
+	for len(paths) > maxQueuedReports {
+		os.Remove(paths[0])
+		paths = paths[1:]
+	}
+	return nil
+}
+
+// queuedReports 返回磁盘队列中的结果文件路径，按生成顺序排序
+func (c *Client) queuedReports() ([]string, error) {
+	entries, err := os.ReadDir(c.reportsDir())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var paths []string
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".json") {
+			paths = append(paths, filepath.Join(c.reportsDir(), entry.Name()))
+		}
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
@@ -0,0 +1,207 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newReportServer 返回记录上报结果的测试服务器，available为false时返回503
+func newReportServer(t *testing.T, available *bool) (*httptest.Server, func() []InstallReport) {
+	t.Helper()
+
+	var mu sync.Mutex
+	var reports []InstallReport
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != reportsPath {
+			http.NotFound(w, r)
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		if !*available {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		var report InstallReport
+		if err := json.NewDecoder(r.Body).Decode(&report); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		reports = append(reports, report)
+		json.NewEncoder(w).Encode(map[string]interface{}{"code": 200, "message": "ok"})
+	}))
+	t.Cleanup(server.Close)
+
+	return server, func() []InstallReport {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]InstallReport(nil), reports...)
+	}
+}
+
+func newReportClient(t *testing.T, serverURL, installDir string, disable bool) *Client {
+	t.Helper()
+
+	c, err := NewClient(&Config{
+		ServerURL:      serverURL,
+		APIKey:         "test-api-key",
+		Platform:       "linux",
+		Arch:           "amd64",
+		InstallDir:     installDir,
+		CurrentVersion: "1.0.0",
+		Retry:          RetryPolicy{MaxAttempts: 1},
+		DisableReports: disable,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestUpdateReportsOutcome(t *testing.T) {
+	available := true
+	server, reports := newReportServer(t, &available)
+	installDir := t.TempDir()
+	c := newReportClient(t, server.URL, installDir, false)
+
+	// 无法识别的更新包导致更新失败
+	packagePath := filepath.Join(t.TempDir(), "update.bin")
+	writeTestFile(t, packagePath, "not an archive")
+	if err := c.Update(context.Background(), &UpdateInfo{LatestVersion: "1.1.0"}, packagePath); err == nil {
+		t.Fatal("Expected update to fail")
+	}
+
+	// 成功安装后手动回滚
+	writeTestFile(t, filepath.Join(installDir, "app"), "old binary")
+	updateDir := t.TempDir()
+	writeTestFile(t, filepath.Join(updateDir, "app"), "new binary")
+	if err := c.installFromDir(context.Background(), &UpdateInfo{LatestVersion: "1.2.0"}, updateDir, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Rollback(context.Background(), "1.2.0"); err != nil {
+		t.Fatal(err)
+	}
+
+	got := reports()
+	if len(got) != 2 {
+		t.Fatalf("Expected 2 reports, got %+v", got)
+	}
+	failed, rolledBack := got[0], got[1]
+	if failed.Status != UpdateStatusFailed || failed.ErrorCode != "EXTRACT_FAILED" ||
+		failed.FromVersion != "1.0.0" || failed.ToVersion != "1.1.0" {
+		t.Errorf("Unexpected failure report: %+v", failed)
+	}
+	if failed.InstallationID != c.InstallationID() || failed.Platform != "linux" || failed.Arch != "amd64" || failed.ID == "" {
+		t.Errorf("Expected report to identify the installation, got %+v", failed)
+	}
+	if rolledBack.Status != UpdateStatusRolledBack || rolledBack.FromVersion != "1.0.0" || rolledBack.ToVersion != "1.2.0" {
+		t.Errorf("Unexpected rollback report: %+v", rolledBack)
+	}
+}
+
+func TestReportsQueuedWhileOffline(t *testing.T) {
+	available := false
+	server, reports := newReportServer(t, &available)
+	installDir := t.TempDir()
+	c := newReportClient(t, server.URL, installDir, false)
+
+	c.reportUpdate(&UpdateInfo{LatestVersion: "1.1.0"}, "1.0.0", time.Now(), nil)
+	err := c.FlushReports(context.Background())
+	var clientErr *ClientError
+	if !errors.As(err, &clientErr) || clientErr.Code != "REPORT_FAILED" {
+		t.Fatalf("Expected REPORT_FAILED while server is unavailable, got %v", err)
+	}
+	queued, _ := c.queuedReports()
+	if len(queued) != 1 {
+		t.Fatalf("Expected report to stay queued, got %v", queued)
+	}
+
+	// 重新创建客户端后上报积压的结果
+	available = true
+	c = newReportClient(t, server.URL, installDir, false)
+	if err := c.FlushReports(context.Background()); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if got := reports(); len(got) != 1 || got[0].Status != UpdateStatusSuccess || got[0].ToVersion != "1.1.0" {
+		t.Errorf("Expected queued success report to be delivered, got %+v", got)
+	}
+	if queued, _ := c.queuedReports(); len(queued) != 0 {
+		t.Errorf("Expected queue to be empty, got %v", queued)
+	}
+}
+
+func TestReportsRejectedInResponseBodyAreDropped(t *testing.T) {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		json.NewEncoder(w).Encode(map[string]interface{}{"code": 400, "message": "invalid report"})
+	}))
+	t.Cleanup(server.Close)
+	c := newReportClient(t, server.URL, t.TempDir(), false)
+
+	c.reportUpdate(&UpdateInfo{LatestVersion: "1.1.0"}, "1.0.0", time.Now(), nil)
+	c.reportUpdate(&UpdateInfo{LatestVersion: "1.2.0"}, "1.1.0", time.Now(), nil)
+	if err := c.FlushReports(context.Background()); err != nil {
+		t.Fatalf("Expected rejected reports to be dropped, got %v", err)
+	}
+	if requests != 2 {
+		t.Errorf("Expected both reports to be sent, got %d requests", requests)
+	}
+	if queued, _ := c.queuedReports(); len(queued) != 0 {
+		t.Errorf("Expected queue to be empty, got %v", queued)
+	}
+}
+
+func TestUpdateDoesNotWaitForSlowReports(t *testing.T) {
+	// 上报接口在测试结束前不响应
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	t.Cleanup(server.Close)
+	t.Cleanup(func() { close(release) })
+	c := newReportClient(t, server.URL, t.TempDir(), false)
+
+	defer func(timeout time.Duration) { reportFlushTimeout = timeout }(reportFlushTimeout)
+	reportFlushTimeout = 50 * time.Millisecond
+
+	packagePath := filepath.Join(t.TempDir(), "update.bin")
+	writeTestFile(t, packagePath, "not an archive")
+	start := time.Now()
+	if err := c.Update(context.Background(), &UpdateInfo{LatestVersion: "1.1.0"}, packagePath); err == nil {
+		t.Fatal("Expected update to fail")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Expected Update to return without waiting for the report endpoint, took %v", elapsed)
+	}
+	if queued, _ := c.queuedReports(); len(queued) != 1 {
+		t.Errorf("Expected unsent report to stay queued, got %v", queued)
+	}
+}
+
+func TestDisableReports(t *testing.T) {
+	available := true
+	server, reports := newReportServer(t, &available)
+	installDir := t.TempDir()
+	c := newReportClient(t, server.URL, installDir, true)
+
+	packagePath := filepath.Join(t.TempDir(), "update.bin")
+	writeTestFile(t, packagePath, "not an archive")
+	c.Update(context.Background(), &UpdateInfo{LatestVersion: "1.1.0"}, packagePath)
+
+	if got := reports(); len(got) != 0 {
+		t.Errorf("Expected no reports, got %+v", got)
+	}
+	if _, err := os.Stat(filepath.Join(installDir, stateDirName, reportsDirName)); !os.IsNotExist(err) {
+		t.Errorf("Expected no report queue, got %v", err)
+	}
+}
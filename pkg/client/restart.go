@@ -0,0 +1,114 @@
+package client
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"time"
+)
+
+// ShutdownHook 重启前执行的关闭钩子，用于关闭监听端口、刷新缓冲等
+type ShutdownHook func(ctx context.Context) error
+
+// exitProcess 退出当前进程（测试时可替换）
+var exitProcess = os.Exit
+
+// RegisterShutdownHook 注册重启前执行的关闭钩子，钩子按注册顺序执行
+func (c *Client) RegisterShutdownHook(hook ShutdownHook) {
+	if hook == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.shutdownHooks = append(c.shutdownHooks, hook)
+}
+
+// Restart 使用相同的参数和环境变量重新执行新安装的可执行文件
+// Linux/macOS下通过syscall.Exec原地替换当前进程（进程ID不变），成功时不会返回；
+// Windows不支持exec，退化为RestartSpawn
+func (c *Client) Restart(ctx context.Context) error {
+	// 使用创建客户端时记录的路径，更新后os.Executable返回的是已删除的旧文件
+	execPath, err := c.execPath, c.execErr
+	if err != nil {
+		return NewClientError("RESTART_FAILED", "Failed to get executable path", err)
+	}
+
+	if err := c.runShutdownHooks(ctx); err != nil {
+		return err
+	}
+
+	if err := execSelf(execPath, os.Args, os.Environ()); err != nil {
+		return NewClientError("RESTART_FAILED", "Failed to exec new executable", err)
+	}
+
+	return nil
+}
+
+// RestartSpawn 以相同的参数和环境变量启动新的进程，随后退出当前进程
+// 适用于由外部进程管理器托管、或不支持exec的平台。
+// 配置了HealthCheck.URL时先轮询该地址，新进程不健康则终止新进程、恢复更新前的备份并返回
+// HEALTH_CHECK_FAILED错误，当前进程继续运行（关闭钩子已执行，由调用方决定后续处理）
+func (c *Client) RestartSpawn(ctx context.Context) error {
+	execPath, err := c.execPath, c.execErr
+	if err != nil {
+		return NewClientError("RESTART_FAILED", "Failed to get executable path", err)
+	}
+
+	if err := c.runShutdownHooks(ctx); err != nil {
+		return err
+	}
+
+	start := time.Now()
+	process, err := spawnProcess(execPath, os.Args[1:], os.Environ())
+	if err != nil {
+		return NewClientError("RESTART_FAILED", "Failed to start new process", err)
+	}
+
+	if err := c.pollHealthURL(ctx); err != nil {
+		process.Kill()
+		process.Wait()
+		record, err := c.healthCheckFailed(err)
+		if record != nil {
+			c.reportRollback(record, start, err)
+			c.flushReportsBriefly(ctx)
+		}
+		return err
+	}
+	process.Release()
+
+	exitProcess(0)
+	return nil
+}
+
+// runShutdownHooks 按注册顺序执行关闭钩子，任一钩子失败则中止重启
+func (c *Client) runShutdownHooks(ctx context.Context) error {
+	c.mu.Lock()
+	hooks := make([]ShutdownHook, len(c.shutdownHooks))
+	copy(hooks, c.shutdownHooks)
+	c.mu.Unlock()
+
+	for _, hook := range hooks {
+		if err := hook(ctx); err != nil {
+			return NewClientError("SHUTDOWN_HOOK_FAILED", "Shutdown hook failed, restart aborted", err)
+		}
+	}
+
+	return nil
+}
+
+// spawnProcess 启动新进程并继承标准输入输出
+func spawnProcess(execPath string, args, env []string) (*os.Process, error) {
+	cmd := exec.Command(execPath, args...)
+	cmd.Env = env
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	return cmd.Process, nil
+}
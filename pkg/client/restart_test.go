@@ -0,0 +1,69 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// restartScript 启动时在所在目录留下标记文件的新版本
+const restartScript = "#!/bin/sh\ntouch \"$(dirname \"$0\")/restarted\"\n"
+
+func TestRestartExecsUpdatedExecutable(t *testing.T) {
+	installDir := t.TempDir()
+	runInstalledCopy(t, installDir, "TestRestartHelper")
+
+	if _, err := os.Stat(filepath.Join(installDir, "restarted")); err != nil {
+		t.Errorf("Expected Restart to exec the updated executable, got %v", err)
+	}
+}
+
+// TestRestartHelper 由TestRestartExecsUpdatedExecutable在子进程中运行，
+// 更新正在运行的可执行文件后重启，成功时进程被新版本替换
+func TestRestartHelper(t *testing.T) {
+	installDir := os.Getenv(selfUpdateDirEnv)
+	if installDir == "" {
+		t.Skip("helper process for TestRestartExecsUpdatedExecutable")
+	}
+
+	c := newTestClient(t, installDir)
+	packagePath := filepath.Join(t.TempDir(), "app")
+	writeTestFile(t, packagePath, restartScript)
+	info := &UpdateInfo{LatestVersion: "1.1.0", CompressionType: "raw"}
+	if err := c.Update(context.Background(), info, packagePath); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	err := c.Restart(context.Background())
+	t.Fatalf("Expected Restart not to return, got %v", err)
+}
+
+func TestRunShutdownHooks(t *testing.T) {
+	c := newTestClient(t, t.TempDir())
+
+	var order []int
+	c.RegisterShutdownHook(func(ctx context.Context) error {
+		order = append(order, 1)
+		return nil
+	})
+	c.RegisterShutdownHook(func(ctx context.Context) error {
+		order = append(order, 2)
+		return errors.New("busy")
+	})
+	c.RegisterShutdownHook(func(ctx context.Context) error {
+		order = append(order, 3)
+		return nil
+	})
+
+	err := c.runShutdownHooks(context.Background())
+	var clientErr *ClientError
+	if !errors.As(err, &clientErr) || clientErr.Code != "SHUTDOWN_HOOK_FAILED" {
+		t.Fatalf("Expected SHUTDOWN_HOOK_FAILED, got %v", err)
+	}
+
+	if len(order) != 2 || order[0] != 1 || order[1] != 2 {
+		t.Errorf("Expected hooks to run in order and stop at first failure, got %v", order)
+	}
+}
@@ -0,0 +1,10 @@
+//go:build !windows
+
+package client
+
+import "syscall"
+
+// execSelf 使用exec系统调用替换当前进程
+func execSelf(execPath string, args, env []string) error {
+	return syscall.Exec(execPath, args, env)
+}
@@ -0,0 +1,15 @@
+//go:build windows
+
+package client
+
+// execSelf Windows不支持exec，启动新进程后退出当前进程
+func execSelf(execPath string, args, env []string) error {
+	process, err := spawnProcess(execPath, args[1:], env)
+	if err != nil {
+		return err
+	}
+	process.Release()
+
+	exitProcess(0)
+	return nil
+}
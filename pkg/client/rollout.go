@@ -0,0 +1,73 @@
+package client
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/CooperJiang/versiontrack-go-sdk/internal/utils"
+)
+
+const (
+	// installationIDName 安装ID文件名称（位于状态目录下）
+	installationIDName = "installation_id"
+	// rolloutBuckets 灰度分桶数量，与RolloutPercentage的百分比一一对应
+	rolloutBuckets = 100
+)
+
+// InstallationID 返回本安装实例的唯一ID，首次创建客户端时随机生成并保存在状态目录中
+func (c *Client) InstallationID() string {
+	return c.installationID
+}
+
+// RolloutBucket 返回本安装实例所在的灰度分桶（0-99），由安装ID确定，同一安装实例始终不变
+// RolloutPercentage为N的版本只对分桶小于N的安装实例可见
+func (c *Client) RolloutBucket() int {
+	return rolloutBucket(c.installationID)
+}
+
+// rolloutBucket 计算安装ID对应的灰度分桶
+func rolloutBucket(installationID string) int {
+	sum := sha256.Sum256([]byte(installationID))
+	return int(binary.BigEndian.Uint64(sum[:8]) % rolloutBuckets)
+}
+
+// inRollout 检查本安装实例是否在版本的灰度范围内，未设置RolloutPercentage的版本对所有安装实例可见
+func (c *Client) inRollout(v *VersionInfo) bool {
+	if v.RolloutPercentage == nil {
+		return true
+	}
+	return c.RolloutBucket() < *v.RolloutPercentage
+}
+
+// loadInstallationID 读取安装ID，不存在时生成新的ID并原子写入
+func (c *Client) loadInstallationID() error {
+	path := filepath.Join(c.stateDir(), installationIDName)
+
+	data, err := os.ReadFile(path)
+	if err == nil {
+		if id := strings.TrimSpace(string(data)); id != "" {
+			c.installationID = id
+			return nil
+		}
+	} else if !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read installation id: %w", err)
+	}
+
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return fmt.Errorf("failed to generate installation id: %w", err)
+	}
+	id := hex.EncodeToString(buf)
+
+	if err := utils.WriteFileAtomic(path, []byte(id+"\n"), 0644); err != nil {
+		return fmt.Errorf("failed to write installation id: %w", err)
+	}
+	c.installationID = id
+	return nil
+}
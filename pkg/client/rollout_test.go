@@ -0,0 +1,110 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestInstallationIDIsStable(t *testing.T) {
+	installDir := t.TempDir()
+	newClient := func() *Client {
+		c, err := NewClient(&Config{
+			ServerURL:  "https://test-server.com",
+			APIKey:     "test-api-key",
+			Platform:   "linux",
+			Arch:       "amd64",
+			InstallDir: installDir,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return c
+	}
+
+	first := newClient()
+	if len(first.InstallationID()) != 32 {
+		t.Fatalf("Expected 32 hex character installation id, got %q", first.InstallationID())
+	}
+	data, err := os.ReadFile(filepath.Join(installDir, stateDirName, installationIDName))
+	if err != nil || strings.TrimSpace(string(data)) != first.InstallationID() {
+		t.Errorf("Expected installation id to be persisted, got %q (%v)", data, err)
+	}
+
+	second := newClient()
+	if second.InstallationID() != first.InstallationID() || second.RolloutBucket() != first.RolloutBucket() {
+		t.Errorf("Expected installation id and bucket to survive restart, got %s/%d and %s/%d",
+			first.InstallationID(), first.RolloutBucket(), second.InstallationID(), second.RolloutBucket())
+	}
+	if bucket := first.RolloutBucket(); bucket < 0 || bucket >= rolloutBuckets {
+		t.Errorf("Expected bucket in [0, %d), got %d", rolloutBuckets, bucket)
+	}
+}
+
+func TestRolloutFiltersUpdates(t *testing.T) {
+	installDir := t.TempDir()
+	// 固定安装ID，使分桶可预期
+	writeTestFile(t, filepath.Join(installDir, stateDirName, installationIDName), "test-installation\n")
+	bucket := rolloutBucket("test-installation")
+
+	inside, outside := bucket+1, bucket
+	var queryBucket string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queryBucket = r.URL.Query().Get("bucket")
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"code":    200,
+			"message": "ok",
+			"data": UpdatesInfo{
+				HasUpdate:     true,
+				LatestVersion: "1.3.0",
+				AvailableVersions: []VersionInfo{
+					{Version: "1.3.0", RolloutPercentage: &outside},
+					{Version: "1.2.0", RolloutPercentage: &inside},
+					{Version: "1.1.0"},
+				},
+			},
+		})
+	}))
+	defer server.Close()
+
+	c, err := NewClient(&Config{
+		ServerURL:  server.URL,
+		APIKey:     "test-api-key",
+		Platform:   "linux",
+		Arch:       "amd64",
+		InstallDir: installDir,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.InstallationID() != "test-installation" || c.RolloutBucket() != bucket {
+		t.Fatalf("Expected existing installation id to be used, got %s/%d", c.InstallationID(), c.RolloutBucket())
+	}
+
+	recommended, err := c.GetRecommendedUpdate(context.Background(), "1.0.0")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if recommended == nil || recommended.Version != "1.2.0" {
+		t.Errorf("Expected 1.2.0 (1.3.0 is outside the rollout cohort), got %+v", recommended)
+	}
+	if queryBucket != strconv.Itoa(bucket) {
+		t.Errorf("Expected bucket query %d, got %q", bucket, queryBucket)
+	}
+
+	// 旧接口同样不提供灰度范围外的版本
+	info, err := c.CheckForUpdates(context.Background(), "1.0.0")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if info.LatestVersion != "1.2.0" || len(info.AvailableVersions) != 2 || info.AvailableVersions[0].Version != "1.2.0" {
+		t.Errorf("Expected CheckForUpdates to offer 1.2.0, got %+v", info)
+	}
+}
@@ -0,0 +1,177 @@
+package client
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// PromptFunc 提示模式下的决策函数，返回true表示同意更新到该版本
+type PromptFunc func(ctx context.Context, version *VersionInfo) bool
+
+// SchedulerOptions 后台更新调度器配置
+type SchedulerOptions struct {
+	// 检查间隔，默认30分钟
+	Interval time.Duration
+	// 随机抖动上限，每次等待会额外增加[0, Jitter)的随机时长，避免大量客户端同时请求
+	Jitter time.Duration
+	// 首次检查前的延迟，为0时启动后立即检查
+	InitialDelay time.Duration
+	// 发现可用更新时的通知回调（所有模式都会调用）
+	OnUpdateAvailable func(version *VersionInfo)
+	// 提示模式下的决策回调（UpdateModePrompt时必须设置）
+	Prompt PromptFunc
+	// 更新成功后的回调
+	OnUpdated func(version *VersionInfo)
+	// 检查或更新失败时的回调
+	OnError func(err error)
+	// 下载进度回调
+	Progress ProgressCallback
+}
+
+// Scheduler 后台更新调度器，按Config.UpdateMode决定发现更新后的行为：
+// auto 自动应用推荐版本；manual 仅通知；prompt 由Prompt回调决定是否更新
+type Scheduler struct {
+	client *Client
+	opts   SchedulerOptions
+
+	// checkMu 保证同一时间只有一次检查在执行
+	checkMu sync.Mutex
+
+	mu     sync.Mutex
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewScheduler 创建后台更新调度器
+func (c *Client) NewScheduler(opts SchedulerOptions) (*Scheduler, error) {
+	if opts.Interval <= 0 {
+		opts.Interval = 30 * time.Minute
+	}
+	if opts.Jitter < 0 {
+		return nil, NewClientError("INVALID_PARAMETER", "Jitter must not be negative", nil)
+	}
+	if c.config.UpdateMode == UpdateModePrompt && opts.Prompt == nil {
+		return nil, NewClientError("INVALID_PARAMETER", "Prompt is required in prompt update mode", nil)
+	}
+
+	return &Scheduler{
+		client: c,
+		opts:   opts,
+	}, nil
+}
+
+// Start 在后台启动调度器，ctx取消或调用Stop后停止
+func (s *Scheduler) Start(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.cancel != nil {
+		return ErrSchedulerRunning
+	}
+
+	runCtx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	s.cancel = cancel
+	s.done = done
+
+	go func() {
+		defer close(done)
+		s.run(runCtx)
+	}()
+
+	return nil
+}
+
+// Stop 停止调度器并等待正在执行的检查结束
+func (s *Scheduler) Stop() {
+	s.mu.Lock()
+	cancel, done := s.cancel, s.done
+	s.cancel, s.done = nil, nil
+	s.mu.Unlock()
+
+	if cancel == nil {
+		return
+	}
+	cancel()
+	<-done
+}
+
+// run 调度循环
+func (s *Scheduler) run(ctx context.Context) {
+	wait := s.opts.InitialDelay
+	for {
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		if err := s.CheckNow(ctx); err != nil && ctx.Err() == nil {
+			s.reportError(err)
+		}
+
+		wait = s.nextInterval()
+	}
+}
+
+// nextInterval 计算下一次检查前的等待时长
+func (s *Scheduler) nextInterval() time.Duration {
+	wait := s.opts.Interval
+	if s.opts.Jitter > 0 {
+		wait += time.Duration(rand.Int63n(int64(s.opts.Jitter)))
+	}
+	return wait
+}
+
+// CheckNow 立即执行一次检查，并按更新模式处理结果
+func (s *Scheduler) CheckNow(ctx context.Context) error {
+	s.checkMu.Lock()
+	defer s.checkMu.Unlock()
+
+	c := s.client
+	// 上报之前未能发送的更新结果，失败时留待下次检查
+	c.FlushReports(ctx)
+
+	version, err := c.GetRecommendedUpdate(ctx, c.GetInstalledVersion())
+	if err != nil {
+		return err
+	}
+	if version == nil {
+		return nil
+	}
+
+	if s.opts.OnUpdateAvailable != nil {
+		s.opts.OnUpdateAvailable(version)
+	}
+
+	switch c.config.UpdateMode {
+	case UpdateModeManual:
+		// 手动模式只通知，不执行更新
+		return nil
+	case UpdateModePrompt:
+		if !s.opts.Prompt(ctx, version) {
+			return nil
+		}
+	}
+
+	if err := c.UpdateToVersion(ctx, version.Version, s.opts.Progress); err != nil {
+		return err
+	}
+
+	if s.opts.OnUpdated != nil {
+		s.opts.OnUpdated(version)
+	}
+
+	return nil
+}
+
+// reportError 调用错误回调
+func (s *Scheduler) reportError(err error) {
+	if s.opts.OnError != nil {
+		s.opts.OnError(err)
+	}
+}
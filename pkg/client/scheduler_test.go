@@ -0,0 +1,235 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newCheckServer(t *testing.T, latest string) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"code":200,"message":"ok","data":{"hasUpdate":true,"currentVersion":%q,"latestVersion":%q,"availableVersions":[{"version":%q,"downloadUrl":"%s/download"}]}}`,
+			r.URL.Query().Get("currentVersion"), latest, latest, "http://"+r.Host)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestSchedulerManualModeOnlyNotifies(t *testing.T) {
+	server := newCheckServer(t, "1.1.0")
+
+	c, err := NewClient(&Config{
+		ServerURL:      server.URL,
+		APIKey:         "test-api-key",
+		Platform:       "linux",
+		Arch:           "amd64",
+		UpdateMode:     UpdateModeManual,
+		InstallDir:     t.TempDir(),
+		CurrentVersion: "1.0.0",
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	notified := make(chan string, 1)
+	scheduler, err := c.NewScheduler(SchedulerOptions{
+		Interval: time.Hour,
+		OnUpdateAvailable: func(version *VersionInfo) {
+			notified <- version.Version
+		},
+		OnUpdated: func(version *VersionInfo) {
+			t.Errorf("Expected manual mode not to apply update %s", version.Version)
+		},
+		OnError: func(err error) {
+			t.Errorf("Expected no error, got %v", err)
+		},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if err := scheduler.Start(context.Background()); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	defer scheduler.Stop()
+
+	if err := scheduler.Start(context.Background()); err != ErrSchedulerRunning {
+		t.Errorf("Expected ErrSchedulerRunning, got %v", err)
+	}
+
+	select {
+	case version := <-notified:
+		if version != "1.1.0" {
+			t.Errorf("Expected notification for 1.1.0, got %s", version)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected update notification")
+	}
+}
+
+// newSchedulerClient 创建已安装oldScript的客户端，配合newPatchServer使用
+func newSchedulerClient(t *testing.T, serverURL string, mode UpdateMode) (*Client, string) {
+	t.Helper()
+
+	installDir := t.TempDir()
+	writeTestFile(t, filepath.Join(installDir, "app.sh"), oldScript)
+
+	c, err := NewClient(&Config{
+		ServerURL:      serverURL,
+		APIKey:         "test-api-key",
+		Platform:       "linux",
+		Arch:           "amd64",
+		UpdateMode:     mode,
+		InstallDir:     installDir,
+		CurrentVersion: "1.0.0",
+		DisableReports: true,
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	return c, installDir
+}
+
+func TestSchedulerAutoModeInstallsRecommendedVersion(t *testing.T) {
+	var fullDownloads int32
+	server := newPatchServer(t, sha256Hex([]byte(newScript)), &fullDownloads)
+	c, installDir := newSchedulerClient(t, server.URL, UpdateModeAuto)
+
+	var updated []string
+	scheduler, err := c.NewScheduler(SchedulerOptions{
+		OnUpdated: func(version *VersionInfo) {
+			updated = append(updated, version.Version)
+		},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if err := scheduler.CheckNow(context.Background()); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	assertFileContent(t, filepath.Join(installDir, "app.sh"), newScript)
+	if version := c.GetInstalledVersion(); version != "1.1.0" {
+		t.Errorf("Expected installed version 1.1.0, got %s", version)
+	}
+	if len(updated) != 1 || updated[0] != "1.1.0" {
+		t.Errorf("Expected OnUpdated for 1.1.0, got %v", updated)
+	}
+}
+
+func TestSchedulerPromptModeRespectsDecision(t *testing.T) {
+	var fullDownloads int32
+	server := newPatchServer(t, sha256Hex([]byte(newScript)), &fullDownloads)
+	c, installDir := newSchedulerClient(t, server.URL, UpdateModePrompt)
+
+	accept := false
+	var prompted []string
+	scheduler, err := c.NewScheduler(SchedulerOptions{
+		Prompt: func(ctx context.Context, version *VersionInfo) bool {
+			prompted = append(prompted, version.Version)
+			return accept
+		},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// 拒绝时不执行更新
+	if err := scheduler.CheckNow(context.Background()); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(prompted) != 1 || prompted[0] != "1.1.0" {
+		t.Fatalf("Expected prompt for 1.1.0, got %v", prompted)
+	}
+	assertFileContent(t, filepath.Join(installDir, "app.sh"), oldScript)
+	if version := c.GetInstalledVersion(); version == "1.1.0" {
+		t.Error("Expected declined update not to be installed")
+	}
+
+	// 同意后执行更新
+	accept = true
+	if err := scheduler.CheckNow(context.Background()); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(prompted) != 2 {
+		t.Errorf("Expected a second prompt, got %v", prompted)
+	}
+	assertFileContent(t, filepath.Join(installDir, "app.sh"), newScript)
+}
+
+func TestSchedulerCheckNowIsSerialized(t *testing.T) {
+	var active, maxActive int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&active, 1)
+		defer atomic.AddInt32(&active, -1)
+		for {
+			max := atomic.LoadInt32(&maxActive)
+			if n <= max || atomic.CompareAndSwapInt32(&maxActive, max, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"code":200,"message":"ok","data":{"hasUpdate":true,"latestVersion":"1.1.0","availableVersions":[{"version":"1.1.0"}]}}`)
+	}))
+	t.Cleanup(server.Close)
+	c, _ := newSchedulerClient(t, server.URL, UpdateModeManual)
+
+	var notified int32
+	scheduler, err := c.NewScheduler(SchedulerOptions{
+		OnUpdateAvailable: func(version *VersionInfo) {
+			atomic.AddInt32(&notified, 1)
+		},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := scheduler.CheckNow(context.Background()); err != nil {
+				t.Errorf("Expected no error, got %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if maxActive != 1 {
+		t.Errorf("Expected checks to run one at a time, got %d concurrent requests", maxActive)
+	}
+	if notified != 5 {
+		t.Errorf("Expected 5 notifications, got %d", notified)
+	}
+}
+
+func TestSchedulerPromptModeRequiresPrompt(t *testing.T) {
+	c, err := NewClient(&Config{
+		ServerURL:  "https://test-server.com",
+		APIKey:     "test-api-key",
+		Platform:   "linux",
+		Arch:       "amd64",
+		UpdateMode: UpdateModePrompt,
+		InstallDir: t.TempDir(),
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if _, err := c.NewScheduler(SchedulerOptions{}); err == nil {
+		t.Error("Expected error when Prompt is missing in prompt mode")
+	}
+}
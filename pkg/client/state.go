@@ -0,0 +1,106 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/CooperJiang/versiontrack-go-sdk/internal/utils"
+)
+
+const (
+	// stateDirName 状态目录名称（位于安装目录下）
+	stateDirName = ".versiontrack"
+	// stateFileName 状态文件名称
+	stateFileName = "state.json"
+)
+
+// clientState 持久化的客户端状态
+type clientState struct {
+	// 当前已安装的版本
+	InstalledVersion string `json:"installedVersion"`
+	// 更新历史
+	History []UpdateRecord `json:"history"`
+	// 各版本安装的文件清单（相对安装目录，使用"/"分隔），用于删除新版本中已移除的文件
+	Manifests map[string][]string `json:"manifests,omitempty"`
+}
+
+// stateDir 返回状态目录路径
+func (c *Client) stateDir() string {
+	return filepath.Join(c.installDir, stateDirName)
+}
+
+// statePath 返回状态文件路径
+func (c *Client) statePath() string {
+	return filepath.Join(c.stateDir(), stateFileName)
+}
+
+// loadState 从磁盘加载状态，文件不存在时视为空状态
+func (c *Client) loadState() error {
+	data, err := os.ReadFile(c.statePath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to read state file: %w", err)
+	}
+
+	var state clientState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return fmt.Errorf("failed to decode state file: %w", err)
+	}
+
+	c.installedVersion = state.InstalledVersion
+	if state.History != nil {
+		c.history = state.History
+	}
+	if state.Manifests != nil {
+		c.manifests = state.Manifests
+	}
+
+	return nil
+}
+
+// manifest 返回指定版本的文件清单，未记录时返回nil
+func (c *Client) manifest(version string) []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.manifests[version]
+}
+
+// pruneManifests 删除已不在更新历史中且非当前安装版本的文件清单，调用方需持有c.mu
+func (c *Client) pruneManifests() {
+	keep := map[string]bool{c.installedVersion: true}
+	for _, record := range c.history {
+		keep[record.Version] = true
+		keep[record.FromVersion] = true
+	}
+
+	for version := range c.manifests {
+		if !keep[version] {
+			delete(c.manifests, version)
+		}
+	}
+}
+
+// saveState 原子写入状态文件，调用方需持有c.mu
+func (c *Client) saveState() error {
+	state := clientState{
+		InstalledVersion: c.installedVersion,
+		History:          c.history,
+		Manifests:        c.manifests,
+	}
+
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode state: %w", err)
+	}
+
+	if err := utils.WriteFileAtomic(c.statePath(), data, 0644); err != nil {
+		return fmt.Errorf("failed to write state file: %w", err)
+	}
+
+	return nil
+}
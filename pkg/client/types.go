@@ -14,16 +14,103 @@ type Config struct {
 	Platform string
 	// 架构信息 (amd64/arm64)
 	Arch string
-	// HTTP请求超时时间
+	// API请求超时时间（不作用于文件下载）
 	Timeout time.Duration
+	// 建立连接（含TLS握手）的超时时间，默认10秒
+	ConnectTimeout time.Duration
+	// 下载停滞超时时间，连续该时长未收到任何数据时中止下载（可重试并续传），默认60秒
+	DownloadStallTimeout time.Duration
 	// 需要保护的文件列表（更新时不覆盖）
 	PreserveFiles []string
 	// 备份保留数量
 	BackupCount int
+	// 备份归档格式 (tar.gz/tar.xz/tar.zst)，默认tar.gz
+	BackupFormat string
+	// 是否保留更新包中文件的setuid/setgid权限位，默认解压时清除
+	AllowSetuid bool
+	// 解压更新包的资源限制，防止解压炸弹
+	ExtractLimits ExtractLimits
 	// 更新模式
 	UpdateMode UpdateMode
 	// 跳过的版本列表
 	SkipVersions []string
+	// 发布渠道 (stable/beta/preview)，默认stable，只接收该渠道及更稳定渠道的版本
+	Channel string
+	// 允许安装的版本范围约束（如"^2"、">=1.4 <2.0"），为空表示不限制
+	AllowedVersions string
+	// 允许安装的最高版本（含），为空表示不限制
+	MaxVersion string
+	// 安装目录（默认为当前可执行文件所在目录），更新历史等状态保存在其下的.versiontrack目录
+	InstallDir string
+	// 当前运行的版本号，尚未通过SDK安装过任何版本时作为已安装版本使用
+	CurrentVersion string
+	// 受信任的签名公钥列表（PEM编码的Ed25519/ECDSA P-256公钥，或base64编码的Ed25519原始公钥）
+	// 配置后应用更新前必须通过签名校验；同时配置多个公钥可实现密钥轮换
+	TrustedPublicKeys []string
+	// 是否要求强哈希校验，开启后拒绝仅提供MD5或未提供哈希的文件
+	RequireStrongHash bool
+	// 请求重试策略（同时作用于API请求和文件下载），零值字段使用默认值
+	Retry RetryPolicy
+	// 下载进度回调的最小间隔，与ProgressStep均未设置时默认200ms
+	ProgressInterval time.Duration
+	// 下载进度回调的百分比步长，进度每增长该值触发一次回调（总大小未知时不生效）
+	ProgressStep float64
+	// 更新后的健康检查，失败时自动恢复更新前的备份
+	HealthCheck HealthCheck
+	// 启动确认，更新后的新版本未调用MarkHealthy确认时在下次启动时回滚
+	BootConfirm BootConfirm
+	// 关闭更新结果上报
+	DisableReports bool
+}
+
+// RetryPolicy 请求重试策略
+type RetryPolicy struct {
+	// 最大尝试次数（含首次请求），默认3，设为1表示不重试
+	MaxAttempts int
+	// 首次重试前的退避时长，之后按指数增长，默认500ms
+	BaseBackoff time.Duration
+	// 单次退避上限，默认30s
+	MaxBackoff time.Duration
+	// 随机抖动比例（0~1），默认0.2
+	Jitter float64
+	// 可重试的HTTP状态码，默认408/429/500/502/503/504；429/503响应的Retry-After会被遵守
+	RetryableStatusCodes []int
+}
+
+// ExtractLimits 解压资源限制，零值字段使用默认值，负数表示不限制
+type ExtractLimits struct {
+	// 解压后的总大小上限（字节），默认8GiB
+	MaxTotalSize int64
+	// 单个文件大小上限（字节），默认4GiB
+	MaxFileSize int64
+	// 条目数量上限，默认100000
+	MaxEntries int
+	// 路径深度上限，默认64
+	MaxDepth int
+}
+
+// HealthCheck 更新后的健康检查配置，Args和URL均未设置时不检查
+type HealthCheck struct {
+	// 冒烟测试参数，设置后Update应用文件后以该参数运行新的可执行文件，退出码为0视为健康
+	Args []string
+	// 健康检查URL，设置后RestartSpawn启动新进程后轮询该地址，返回2xx视为健康
+	URL string
+	// 冒烟测试的超时时间，以及轮询URL的总时长，默认30秒
+	Timeout time.Duration
+	// 轮询URL的间隔（同时作为单次请求的超时），默认1秒
+	Interval time.Duration
+}
+
+// BootConfirm 启动确认配置
+// 启用后每次更新都会写入待确认标记，新版本需在期限和最大启动次数内调用MarkHealthy，
+// 否则下次创建客户端时自动恢复更新前的备份
+type BootConfirm struct {
+	// 是否启用启动确认
+	Enabled bool
+	// 新版本首次启动后的确认期限，默认10分钟
+	Timeout time.Duration
+	// 未确认时允许的启动次数，默认1（新版本首次启动后未确认即退出或崩溃，下次启动时回滚）
+	MaxBootAttempts int
 }
 
 // UpdateMode 更新模式
@@ -68,8 +155,13 @@ type UpdateFile struct {
 	Signature         string `json:"signature"`
 	SignatureAlgorithm string `json:"signatureAlgorithm"`
 	UploadStatus      string `json:"uploadStatus"`
+	BaseVersion       string `json:"baseVersion"` // 补丁文件的基础版本（FileType为patch时使用）
+	TargetHash        string `json:"targetHash"`  // 打补丁后目标文件的哈希值（FileType为patch时使用）
 }
 
+// FileTypePatch 二进制差分补丁（bsdiff格式）文件类型，FilePath为安装目录下被修补文件的相对路径
+const FileTypePatch = "patch"
+
 // UpdateInfo 更新信息（旧版本，保持兼容）
 type UpdateInfo struct {
 	// 是否有更新
@@ -86,12 +178,20 @@ type UpdateInfo struct {
 	DownloadURL string `json:"-"`
 	// 文件大小 (从第一个匹配的文件获取)
 	FileSize int64 `json:"-"`
-	// MD5哈希值 (从第一个匹配的文件获取)
+	// MD5哈希值 (从第一个匹配的文件获取，已废弃，请使用FileHash)
 	MD5Hash string `json:"-"`
+	// 文件哈希值 (支持"sha256:..."等算法前缀，无前缀时按长度推断算法)
+	FileHash string `json:"-"`
 	// 发布说明 (从版本详情获取)
 	ReleaseNotes string `json:"-"`
 	// 发布时间 (从版本详情获取)
 	PublishedAt string `json:"-"`
+	// 更新包签名 (base64编码，从版本或匹配的文件获取)
+	Signature string `json:"-"`
+	// 签名算法 (ed25519/ecdsa-p256-sha256)
+	SignatureAlgorithm string `json:"-"`
+	// 压缩类型 (tar.gz/zip，从匹配的文件获取，为空时按文件扩展名或文件头识别)
+	CompressionType string `json:"-"`
 	// 可用版本列表（新增）
 	AvailableVersions []VersionInfo `json:"availableVersions"`
 	// 更新策略（新增）
@@ -106,6 +206,8 @@ type UpdatesInfo struct {
 	CurrentVersion string `json:"currentVersion"`
 	// 最新版本
 	LatestVersion string `json:"latestVersion"`
+	// 最新版本的更新文件列表
+	UpdateFiles []UpdateFile `json:"updateFiles,omitempty"`
 	// 可用版本列表
 	AvailableVersions []VersionInfo `json:"availableVersions"`
 	// 更新策略
@@ -126,6 +228,16 @@ type VersionInfo struct {
 	DownloadableStatus string `json:"downloadableStatus"` // 下载状态描述
 	ScheduledReleaseAt string `json:"scheduledReleaseAt"` // 预定发布时间（scheduled状态使用）
 	IsForced           bool   `json:"isForced"`           // 是否强制更新
+	Signature          string `json:"signature"`          // 更新包签名（base64编码）
+	SignatureAlgorithm string `json:"signatureAlgorithm"` // 签名算法
+
+	// 发布渠道 (stable/beta/preview)，为空时按Status和预发布标识推断
+	Channel string `json:"channel,omitempty"`
+	// 灰度发布百分比 (0-100)，只有灰度分桶小于该值的安装实例可见，为空表示全量发布
+	RolloutPercentage *int `json:"rolloutPercentage,omitempty"`
+
+	// 更新文件列表，可包含从旧版本升级到该版本的差分补丁（FileType为patch）
+	UpdateFiles []UpdateFile `json:"updateFiles,omitempty"`
 }
 
 // UpdateStrategy 更新策略
@@ -134,16 +246,55 @@ type UpdateStrategy struct {
 	MinRequiredVersion string `json:"minRequiredVersion"`
 }
 
+// 更新记录状态
+const (
+	UpdateStatusSuccess    = "success"     // 更新成功
+	UpdateStatusFailed     = "failed"      // 更新失败（用于上报和启动确认回滚失败）
+	UpdateStatusRolledBack = "rolled_back" // 已回滚
+)
+
+// InstallReport 上报给服务器的更新结果
+type InstallReport struct {
+	// 报告ID，重复上报时服务器可据此去重
+	ID string `json:"id"`
+	// 安装实例ID
+	InstallationID string `json:"installationId"`
+	// 更新前的版本号
+	FromVersion string `json:"fromVersion"`
+	// 目标版本号
+	ToVersion string `json:"toVersion"`
+	// 更新结果 (success/failed/rolled_back)
+	Status string `json:"status"`
+	// 失败或回滚的错误码（ClientError.Code）
+	ErrorCode string `json:"errorCode,omitempty"`
+	// 失败或回滚的错误信息
+	ErrorMessage string `json:"errorMessage,omitempty"`
+	// 耗时（毫秒）
+	DurationMs int64 `json:"durationMs"`
+	// 平台
+	Platform string `json:"platform"`
+	// 架构
+	Arch string `json:"arch"`
+	// 发布渠道
+	Channel string `json:"channel"`
+	// 生成时间
+	ReportedAt time.Time `json:"reportedAt"`
+}
+
 // UpdateRecord 更新记录
 type UpdateRecord struct {
 	// 版本号
 	Version string `json:"version"`
+	// 更新前的版本号
+	FromVersion string `json:"fromVersion,omitempty"`
 	// 更新时间
 	UpdatedAt time.Time `json:"updatedAt"`
 	// 更新状态
 	Status string `json:"status"`
 	// 备份路径
 	BackupPath string `json:"backupPath"`
+	// 失败原因（如健康检查失败导致回滚）
+	Error string `json:"error,omitempty"`
 }
 
 // DownloadProgress 下载进度信息
@@ -152,10 +303,14 @@ type DownloadProgress struct {
 	Downloaded int64
 	// 总字节数
 	Total int64
-	// 下载速度 (bytes/second)
+	// 下载速度 (bytes/second，平滑后的速率)
 	Speed int64
-	// 百分比
+	// 百分比 (总大小未知时为0)
 	Percentage float64
+	// 已用时间
+	Elapsed time.Duration
+	// 预计剩余时间 (总大小或速度未知时为0)
+	Remaining time.Duration
 }
 
 // ProgressCallback 下载进度回调函数
@@ -0,0 +1,159 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/CooperJiang/versiontrack-go-sdk/pkg/client"
+	"github.com/CooperJiang/versiontrack-go-sdk/pkg/versiontracktest"
+)
+
+func newFakeServer(t *testing.T) *versiontracktest.Server {
+	t.Helper()
+
+	server := versiontracktest.NewServer()
+	t.Cleanup(server.Close)
+	return server
+}
+
+func newFlowClient(t *testing.T, serverURL, installDir string, configure func(*client.Config)) *client.Client {
+	t.Helper()
+
+	config := &client.Config{
+		ServerURL:      serverURL,
+		APIKey:         "test-api-key",
+		Platform:       "linux",
+		Arch:           "amd64",
+		InstallDir:     installDir,
+		CurrentVersion: "1.0.0",
+		Retry:          client.RetryPolicy{MaxAttempts: 2, BaseBackoff: 10 * time.Millisecond},
+	}
+	if configure != nil {
+		configure(config)
+	}
+
+	c, err := client.NewClient(config)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	return c
+}
+
+func assertInstalled(t *testing.T, installDir, name, expected string) {
+	t.Helper()
+
+	content, err := os.ReadFile(filepath.Join(installDir, name))
+	if err != nil || string(content) != expected {
+		t.Errorf("Expected %s to contain %q, got %q (%v)", name, expected, content, err)
+	}
+}
+
+func TestCheckForUpdates(t *testing.T) {
+	server := newFakeServer(t)
+	server.MustAddVersion(versiontracktest.Release{Version: "1.0.0", Files: map[string]string{"app": "v1"}})
+	server.MustAddVersion(versiontracktest.Release{Version: "1.2.0", Files: map[string]string{"app": "v1.2"}})
+	server.MustAddVersion(versiontracktest.Release{Version: "1.1.0", Files: map[string]string{"app": "v1.1"}, IsForced: true})
+	server.MustAddVersion(versiontracktest.Release{Version: "2.0.0", Files: map[string]string{"app": "v2"}, Platform: "windows"})
+
+	c := newFlowClient(t, server.URL, t.TempDir(), func(config *client.Config) { config.Channel = client.ChannelBeta })
+
+	info, err := c.CheckForUpdates(context.Background(), "1.0.0")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !info.HasUpdate || info.LatestVersion != "1.2.0" || len(info.AvailableVersions) != 2 {
+		t.Errorf("Expected 1.2.0 and 1.1.0 to be available, got %+v", info)
+	}
+	if info.CompressionType != "tar.gz" || info.FileHash == "" || !info.UpdateStrategy.HasForced {
+		t.Errorf("Expected package details and forced strategy, got %+v", info)
+	}
+
+	requests := server.CheckRequests()
+	if len(requests) != 1 {
+		t.Fatalf("Expected 1 check request, got %d", len(requests))
+	}
+	query := requests[0]
+	if query.Get("platform") != "linux" || query.Get("currentVersion") != "1.0.0" ||
+		query.Get("channel") != client.ChannelBeta || query.Get("bucket") == "" {
+		t.Errorf("Unexpected check query: %v", query)
+	}
+}
+
+func TestUpdateToVersionEndToEnd(t *testing.T) {
+	server := newFakeServer(t)
+	info := server.MustAddVersion(versiontracktest.Release{
+		Version: "1.1.0",
+		Files:   map[string]string{"app": "new binary", "lib/plugin.so": "plugin"},
+	})
+	// 第一次检查返回500，第一次下载在中途断开
+	server.InjectFault(versiontracktest.EndpointCheck, versiontracktest.Fault{Status: 500, Times: 1})
+	server.InjectFault(versiontracktest.EndpointDownload, versiontracktest.Fault{TruncateAfter: 10, Times: 1})
+
+	installDir := t.TempDir()
+	os.WriteFile(filepath.Join(installDir, "app"), []byte("old binary"), 0755)
+	c := newFlowClient(t, server.URL, installDir, nil)
+
+	if err := c.UpdateToVersion(context.Background(), "1.1.0", nil); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	assertInstalled(t, installDir, "app", "new binary")
+	assertInstalled(t, installDir, "lib/plugin.so", "plugin")
+	if c.GetInstalledVersion() != "1.1.0" {
+		t.Errorf("Expected installed version 1.1.0, got %s", c.GetInstalledVersion())
+	}
+	if n := server.Downloads(info.UpdateFiles[0]); n != 2 {
+		t.Errorf("Expected truncated download to be resumed once, got %d downloads", n)
+	}
+
+	reports := server.Reports()
+	if len(reports) != 1 || reports[0].Status != client.UpdateStatusSuccess ||
+		reports[0].FromVersion != "1.0.0" || reports[0].ToVersion != "1.1.0" {
+		t.Errorf("Expected a success report, got %+v", reports)
+	}
+}
+
+func TestUpdateToVersionRejectsCorruptDownload(t *testing.T) {
+	server := newFakeServer(t)
+	info := server.MustAddVersion(versiontracktest.Release{Version: "1.1.0", Files: map[string]string{"app": "new binary"}})
+	server.InjectFault(versiontracktest.EndpointDownload, versiontracktest.Fault{Corrupt: true})
+
+	installDir := t.TempDir()
+	os.WriteFile(filepath.Join(installDir, "app"), []byte("old binary"), 0755)
+	c := newFlowClient(t, server.URL, installDir, nil)
+
+	err := c.UpdateToVersion(context.Background(), "1.1.0", nil)
+	if !errors.Is(err, client.ErrVerificationFailed) {
+		t.Fatalf("Expected verification error, got %v", err)
+	}
+	assertInstalled(t, installDir, "app", "old binary")
+	if n := server.Downloads(info.UpdateFiles[0]); n != 1 {
+		t.Errorf("Expected 1 download, got %d", n)
+	}
+
+	reports := server.Reports()
+	if len(reports) != 1 || reports[0].Status != client.UpdateStatusFailed || reports[0].ErrorCode != "VERIFY_FAILED" {
+		t.Errorf("Expected a VERIFY_FAILED report, got %+v", reports)
+	}
+}
+
+func TestUpdateToVersionStalledDownload(t *testing.T) {
+	server := newFakeServer(t)
+	server.MustAddVersion(versiontracktest.Release{Version: "1.1.0", Files: map[string]string{"app": "new binary"}})
+	server.InjectFault(versiontracktest.EndpointDownload, versiontracktest.Fault{Delay: time.Second})
+
+	c := newFlowClient(t, server.URL, t.TempDir(), func(config *client.Config) {
+		config.DownloadStallTimeout = 100 * time.Millisecond
+		config.Retry.MaxAttempts = 1
+	})
+
+	err := c.UpdateToVersion(context.Background(), "1.1.0", nil)
+	var clientErr *client.ClientError
+	if !errors.As(err, &clientErr) || clientErr.Code != "DOWNLOAD_FAILED" {
+		t.Fatalf("Expected DOWNLOAD_FAILED, got %v", err)
+	}
+}
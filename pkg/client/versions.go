@@ -0,0 +1,116 @@
+package client
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/CooperJiang/versiontrack-go-sdk/pkg/version"
+)
+
+// isSkipped 检查版本是否在跳过列表中
+// 跳过列表的每一项可以是具体版本（按语义化版本比较，忽略"v"前缀和构建元数据）或版本范围约束（如"<1.2"、"1.5.x"），
+// 无法按语义化版本解析时按字符串比较
+func (c *Client) isSkipped(v string) bool {
+	parsed, err := version.Parse(v)
+	for _, skip := range c.config.SkipVersions {
+		if skip == v {
+			return true
+		}
+		if err != nil {
+			continue
+		}
+		if constraints, err := version.ParseConstraints(skip); err == nil && constraints.Check(parsed) {
+			return true
+		}
+	}
+	return false
+}
+
+// sameVersion 检查两个版本号是否表示同一版本，无法解析时按字符串比较
+func sameVersion(a, b string) bool {
+	if a == b {
+		return true
+	}
+	c, err := version.Compare(a, b)
+	return err == nil && c == 0
+}
+
+// compareVersionInfo 比较两个版本的先后，优先按语义化版本比较，无法解析时按VersionWeight比较
+func compareVersionInfo(a, b *VersionInfo) int {
+	if c, err := version.Compare(a.Version, b.Version); err == nil {
+		return c
+	}
+	switch {
+	case a.VersionWeight < b.VersionWeight:
+		return -1
+	case a.VersionWeight > b.VersionWeight:
+		return 1
+	}
+	return 0
+}
+
+// findVersion 在可用版本中查找目标版本
+// target可以是具体版本（按语义化版本匹配），也可以是版本范围约束（如"~1.5"），
+// 此时返回满足约束且不在跳过列表中的最高版本
+func (c *Client) findVersion(versions []VersionInfo, target string) *VersionInfo {
+	for i := range versions {
+		if sameVersion(versions[i].Version, target) {
+			return &versions[i]
+		}
+	}
+
+	if _, err := version.Parse(target); err == nil {
+		return nil
+	}
+	constraints, err := version.ParseConstraints(target)
+	if err != nil {
+		return nil
+	}
+
+	var candidates []*VersionInfo
+	for i := range versions {
+		v, err := version.Parse(versions[i].Version)
+		if err == nil && constraints.Check(v) && !c.isSkipped(versions[i].Version) {
+			candidates = append(candidates, &versions[i])
+		}
+	}
+	return latestVersion(candidates)
+}
+
+// latestVersion 返回列表中最高的版本，列表为空时返回nil
+func latestVersion(versions []*VersionInfo) *VersionInfo {
+	if len(versions) == 0 {
+		return nil
+	}
+
+	sorted := append([]*VersionInfo(nil), versions...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return compareVersionInfo(sorted[i], sorted[j]) > 0
+	})
+	return sorted[0]
+}
+
+// checkVersionWeights 校验服务器返回的VersionWeight与语义化版本顺序一致
+// 只比较两者都能按语义化版本解析且都提供了权重的版本
+func checkVersionWeights(versions []VersionInfo) error {
+	for i := range versions {
+		a, err := version.Parse(versions[i].Version)
+		if err != nil || versions[i].VersionWeight == 0 {
+			continue
+		}
+		for j := i + 1; j < len(versions); j++ {
+			b, err := version.Parse(versions[j].Version)
+			if err != nil || versions[j].VersionWeight == 0 {
+				continue
+			}
+
+			cmp := a.Compare(b)
+			weightA, weightB := versions[i].VersionWeight, versions[j].VersionWeight
+			if (cmp < 0 && weightA >= weightB) || (cmp > 0 && weightA <= weightB) || (cmp == 0 && weightA != weightB) {
+				return fmt.Errorf("%w: %s (weight %d) and %s (weight %d)",
+					ErrVersionInconsistent, versions[i].Version, weightA, versions[j].Version, weightB)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,130 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func newVersionsServer(t *testing.T, versions []VersionInfo) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"code":    200,
+			"message": "ok",
+			"data": UpdatesInfo{
+				HasUpdate:         len(versions) > 0,
+				CurrentVersion:    r.URL.Query().Get("currentVersion"),
+				LatestVersion:     versions[0].Version,
+				AvailableVersions: versions,
+			},
+		})
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func newVersionsClient(t *testing.T, serverURL string, skip []string) *Client {
+	t.Helper()
+
+	c, err := NewClient(&Config{
+		ServerURL:    serverURL,
+		APIKey:       "test-api-key",
+		Platform:     "linux",
+		Arch:         "amd64",
+		InstallDir:   t.TempDir(),
+		SkipVersions: skip,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestIsSkipped(t *testing.T) {
+	c := newVersionsClient(t, "https://test-server.com", []string{"1.2.3", "<1.0", "2.1.x", "nightly"})
+
+	testCases := map[string]bool{
+		"1.2.3":       true,
+		"v1.2.3+b1":   true,
+		"1.2.4":       false,
+		"0.9.0":       true,
+		"2.1.7":       true,
+		"2.2.0":       false,
+		"nightly":     true,
+		"not-semver":  false,
+		"2.1.0-beta1": false,
+	}
+	for v, expected := range testCases {
+		if got := c.isSkipped(v); got != expected {
+			t.Errorf("isSkipped(%q): expected %v, got %v", v, expected, got)
+		}
+	}
+}
+
+func TestGetRecommendedUpdateSkipsVersions(t *testing.T) {
+	server := newVersionsServer(t, []VersionInfo{
+		{Version: "1.10.0"},
+		{Version: "1.9.0"},
+		{Version: "1.11.0"},
+		{Version: "1.12.0"},
+	})
+	c := newVersionsClient(t, server.URL, []string{">=1.12"})
+
+	recommended, err := c.GetRecommendedUpdate(context.Background(), "1.8.0")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if recommended == nil || recommended.Version != "1.11.0" {
+		t.Errorf("Expected highest non-skipped version 1.11.0, got %+v", recommended)
+	}
+}
+
+func TestFindVersionPinsConstraint(t *testing.T) {
+	c := newVersionsClient(t, "https://test-server.com", []string{"1.5.3"})
+	versions := []VersionInfo{{Version: "1.6.0"}, {Version: "1.5.3"}, {Version: "1.5.2"}, {Version: "v1.4.0"}}
+
+	testCases := map[string]string{
+		"1.5.2": "1.5.2",
+		"1.4.0": "v1.4.0",
+		"~1.5":  "1.5.2",
+		"^1":    "1.6.0",
+		"<1.5":  "v1.4.0",
+		"2.0.0": "",
+		"^3":    "",
+	}
+	for target, expected := range testCases {
+		found := c.findVersion(versions, target)
+		if expected == "" {
+			if found != nil {
+				t.Errorf("findVersion(%q): expected no match, got %s", target, found.Version)
+			}
+			continue
+		}
+		if found == nil || found.Version != expected {
+			t.Errorf("findVersion(%q): expected %s, got %+v", target, expected, found)
+		}
+	}
+}
+
+func TestCheckForMultipleUpdatesRejectsInconsistentWeights(t *testing.T) {
+	server := newVersionsServer(t, []VersionInfo{
+		{Version: "2.0.0", VersionWeight: 100},
+		{Version: "10.0.0", VersionWeight: 50},
+	})
+	c := newVersionsClient(t, server.URL, nil)
+
+	_, err := c.CheckForMultipleUpdates(context.Background(), "1.0.0")
+	var clientErr *ClientError
+	if !errors.As(err, &clientErr) || clientErr.Code != "VERSION_INCONSISTENT" {
+		t.Fatalf("Expected VERSION_INCONSISTENT, got %v", err)
+	}
+	if !errors.Is(err, ErrVersionInconsistent) {
+		t.Errorf("Expected error to match ErrVersionInconsistent, got %v", err)
+	}
+}
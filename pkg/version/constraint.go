@@ -0,0 +1,290 @@
+package version
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrInvalidConstraint 版本约束格式无效错误
+var ErrInvalidConstraint = errors.New("invalid version constraint")
+
+// comparator 单个比较条件
+type comparator struct {
+	op      string
+	version Version
+}
+
+// check 检查版本是否满足比较条件
+func (c comparator) check(v Version) bool {
+	cmp := v.Compare(c.version)
+	switch c.op {
+	case "=":
+		return cmp == 0
+	case "!=":
+		return cmp != 0
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	}
+	return false
+}
+
+// Constraints 版本范围约束
+// 空格或逗号分隔的条件需同时满足，"||"分隔的条件组满足其一即可，例如">=1.4 <2.0"、"~1.5 || ^2"。
+// 支持的运算符：=、!=、>、>=、<、<=、~（允许补丁版本升级）、^（允许不改变最左侧非零位的升级），
+// 不带运算符的版本等同于"="；版本号可省略次版本号或补丁号，也可以使用x/*通配（如"1.x"）。
+// 预发布版本只有在同一条件组中存在相同主/次/补丁号的预发布条件时才能满足约束
+type Constraints struct {
+	groups   [][]comparator
+	original string
+}
+
+// ParseConstraints 解析版本范围约束
+func ParseConstraints(s string) (Constraints, error) {
+	c := Constraints{original: strings.TrimSpace(s)}
+
+	groups := strings.Split(s, "||")
+	for _, group := range groups {
+		// "||"两侧的条件组不能为空，否则"^2 ||"会匹配所有版本
+		if len(groups) > 1 && strings.Trim(group, " ,\t") == "" {
+			return Constraints{}, fmt.Errorf("%w: %q: empty group", ErrInvalidConstraint, s)
+		}
+		tokens, err := constraintTokens(group)
+		if err != nil {
+			return Constraints{}, fmt.Errorf("%w: %q", ErrInvalidConstraint, s)
+		}
+
+		var comparators []comparator
+		for _, token := range tokens {
+			parsed, err := parseComparator(token)
+			if err != nil {
+				return Constraints{}, fmt.Errorf("%w: %q: %v", ErrInvalidConstraint, s, err)
+			}
+			comparators = append(comparators, parsed...)
+		}
+		c.groups = append(c.groups, comparators)
+	}
+
+	return c, nil
+}
+
+// MustParseConstraints 解析版本范围约束，格式无效时panic
+func MustParseConstraints(s string) Constraints {
+	c, err := ParseConstraints(s)
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
+
+// Check 检查版本是否满足约束
+func (c Constraints) Check(v Version) bool {
+	for _, group := range c.groups {
+		if checkGroup(group, v) {
+			return true
+		}
+	}
+	return false
+}
+
+// String 返回约束的原始字符串
+func (c Constraints) String() string {
+	return c.original
+}
+
+// checkGroup 检查版本是否满足条件组中的全部条件
+func checkGroup(group []comparator, v Version) bool {
+	for _, c := range group {
+		if !c.check(v) {
+			return false
+		}
+	}
+
+	if !v.IsPrerelease() {
+		return true
+	}
+	for _, c := range group {
+		if c.version.IsPrerelease() && c.version.Major == v.Major && c.version.Minor == v.Minor && c.version.Patch == v.Patch {
+			return true
+		}
+	}
+	return false
+}
+
+// constraintTokens 按空格和逗号拆分条件组，将与版本号分开书写的运算符（如">= 1.4"）合并
+func constraintTokens(group string) ([]string, error) {
+	fields := strings.FieldsFunc(group, func(r rune) bool {
+		return r == ' ' || r == ',' || r == '\t'
+	})
+
+	var tokens []string
+	for i := 0; i < len(fields); i++ {
+		token := fields[i]
+		if strings.TrimLeft(token, "=!<>~^") == "" {
+			if i+1 >= len(fields) {
+				return nil, ErrInvalidConstraint
+			}
+			i++
+			token += fields[i]
+		}
+		tokens = append(tokens, token)
+	}
+
+	// 空约束匹配所有版本
+	if len(tokens) == 0 {
+		tokens = []string{"*"}
+	}
+	return tokens, nil
+}
+
+// partial 可能省略部分版本号的版本，省略或通配的部分为-1
+type partial struct {
+	major, minor, patch int64
+	prerelease          string
+}
+
+// full 将省略的部分补0得到完整版本
+func (p partial) full() Version {
+	v := Version{Prerelease: p.prerelease}
+	if p.major > 0 {
+		v.Major = uint64(p.major)
+	}
+	if p.minor > 0 {
+		v.Minor = uint64(p.minor)
+	}
+	if p.patch > 0 {
+		v.Patch = uint64(p.patch)
+	}
+	return v
+}
+
+// next 返回省略部分的上一级加1的版本，用于计算范围上界（如1.4 -> 1.5.0，1 -> 2.0.0）
+func (p partial) next() Version {
+	switch {
+	case p.minor < 0:
+		return Version{Major: uint64(p.major) + 1}
+	case p.patch < 0:
+		return Version{Major: uint64(p.major), Minor: uint64(p.minor) + 1}
+	}
+	return Version{Major: uint64(p.major), Minor: uint64(p.minor), Patch: uint64(p.patch) + 1}
+}
+
+// parsePartial 解析可省略部分版本号的版本
+func parsePartial(s string) (partial, error) {
+	p := partial{major: -1, minor: -1, patch: -1}
+	str := strings.TrimPrefix(s, "v")
+
+	if i := strings.IndexByte(str, '+'); i >= 0 {
+		str = str[:i]
+	}
+	if i := strings.IndexByte(str, '-'); i >= 0 {
+		p.prerelease = str[i+1:]
+		str = str[:i]
+		if !validIdentifiers(p.prerelease, true) {
+			return partial{}, fmt.Errorf("invalid prerelease in %q", s)
+		}
+	}
+
+	parts := strings.Split(str, ".")
+	if len(parts) > 3 {
+		return partial{}, fmt.Errorf("invalid version %q", s)
+	}
+	fields := []*int64{&p.major, &p.minor, &p.patch}
+	wildcard := false
+	for i, part := range parts {
+		if part == "x" || part == "X" || part == "*" {
+			wildcard = true
+			continue
+		}
+		n, err := parseNumber(part)
+		if err != nil || wildcard {
+			return partial{}, fmt.Errorf("invalid version %q", s)
+		}
+		*fields[i] = int64(n)
+	}
+
+	// 预发布标识只能用于完整版本
+	if p.prerelease != "" && p.patch < 0 {
+		return partial{}, fmt.Errorf("invalid version %q", s)
+	}
+	return p, nil
+}
+
+// parseComparator 将单个条件展开为一个或多个比较条件
+func parseComparator(token string) ([]comparator, error) {
+	op := token[:len(token)-len(strings.TrimLeft(token, "=!<>~^"))]
+	p, err := parsePartial(token[len(op):])
+	if err != nil {
+		return nil, err
+	}
+
+	isFull := p.patch >= 0
+	wildcard := p.major < 0
+
+	switch op {
+	case "", "=", "==":
+		if wildcard {
+			return []comparator{{">=", Version{}}}, nil
+		}
+		if isFull {
+			return []comparator{{"=", p.full()}}, nil
+		}
+		return []comparator{{">=", p.full()}, {"<", p.next()}}, nil
+	case "!=":
+		if !isFull {
+			return nil, fmt.Errorf("operator != requires a full version: %q", token)
+		}
+		return []comparator{{"!=", p.full()}}, nil
+	case ">":
+		if wildcard {
+			return []comparator{{"<", Version{}}}, nil
+		}
+		if isFull {
+			return []comparator{{">", p.full()}}, nil
+		}
+		return []comparator{{">=", p.next()}}, nil
+	case ">=":
+		return []comparator{{">=", p.full()}}, nil
+	case "<":
+		return []comparator{{"<", p.full()}}, nil
+	case "<=":
+		if wildcard {
+			return []comparator{{">=", Version{}}}, nil
+		}
+		if isFull {
+			return []comparator{{"<=", p.full()}}, nil
+		}
+		return []comparator{{"<", p.next()}}, nil
+	case "~":
+		if wildcard {
+			return []comparator{{">=", Version{}}}, nil
+		}
+		upper := Version{Major: uint64(p.major) + 1}
+		if p.minor >= 0 {
+			upper = Version{Major: uint64(p.major), Minor: uint64(p.minor) + 1}
+		}
+		return []comparator{{">=", p.full()}, {"<", upper}}, nil
+	case "^":
+		if wildcard {
+			return []comparator{{">=", Version{}}}, nil
+		}
+		var upper Version
+		switch {
+		case p.major > 0 || p.minor < 0:
+			upper = Version{Major: uint64(p.major) + 1}
+		case p.minor > 0 || p.patch < 0:
+			upper = Version{Minor: uint64(p.minor) + 1}
+		default:
+			upper = Version{Patch: uint64(p.patch) + 1}
+		}
+		return []comparator{{">=", p.full()}, {"<", upper}}, nil
+	}
+
+	return nil, fmt.Errorf("unknown operator %q", op)
+}
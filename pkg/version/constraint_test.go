@@ -0,0 +1,63 @@
+package version
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestConstraintsCheck(t *testing.T) {
+	testCases := []struct {
+		constraint string
+		matches    []string
+		rejects    []string
+	}{
+		{constraint: ">=1.4 <2.0", matches: []string{"1.4.0", "1.9.9"}, rejects: []string{"1.3.9", "2.0.0", "2.0.0-beta"}},
+		{constraint: ">=1.4, <2.0", matches: []string{"1.5.0"}, rejects: []string{"2.1.0"}},
+		{constraint: ">= 1.4.2", matches: []string{"1.4.2", "3.0.0"}, rejects: []string{"1.4.1"}},
+		{constraint: "~1.5", matches: []string{"1.5.0", "1.5.9"}, rejects: []string{"1.4.9", "1.6.0"}},
+		{constraint: "~1.5.3", matches: []string{"1.5.3", "1.5.10"}, rejects: []string{"1.5.2", "1.6.0"}},
+		{constraint: "~1", matches: []string{"1.0.0", "1.9.0"}, rejects: []string{"2.0.0"}},
+		{constraint: "^2", matches: []string{"2.0.0", "2.9.9"}, rejects: []string{"1.9.9", "3.0.0"}},
+		{constraint: "^1.2.3", matches: []string{"1.2.3", "1.9.0"}, rejects: []string{"1.2.2", "2.0.0"}},
+		{constraint: "^0.2.3", matches: []string{"0.2.3", "0.2.9"}, rejects: []string{"0.3.0"}},
+		{constraint: "^0.0.3", matches: []string{"0.0.3"}, rejects: []string{"0.0.4"}},
+		{constraint: "1.2.x", matches: []string{"1.2.0", "1.2.7"}, rejects: []string{"1.3.0"}},
+		{constraint: "1.2", matches: []string{"1.2.5"}, rejects: []string{"1.3.0"}},
+		{constraint: "1.2.3", matches: []string{"1.2.3", "v1.2.3+build"}, rejects: []string{"1.2.4"}},
+		{constraint: "!=1.2.3", matches: []string{"1.2.4"}, rejects: []string{"1.2.3"}},
+		{constraint: ">1.2", matches: []string{"1.3.0"}, rejects: []string{"1.2.9"}},
+		{constraint: "<=1.2", matches: []string{"1.2.9"}, rejects: []string{"1.3.0"}},
+		{constraint: "*", matches: []string{"0.0.1", "5.0.0"}, rejects: []string{"5.0.0-beta"}},
+		{constraint: "~1.5 || ^3", matches: []string{"1.5.2", "3.1.0"}, rejects: []string{"2.0.0"}},
+		{constraint: ">=1.5.0-beta.2 <2", matches: []string{"1.5.0-beta.3", "1.5.0", "1.6.0"}, rejects: []string{"1.5.0-beta.1", "1.6.0-beta"}},
+	}
+
+	for _, tc := range testCases {
+		c, err := ParseConstraints(tc.constraint)
+		if err != nil {
+			t.Errorf("ParseConstraints(%q): expected no error, got %v", tc.constraint, err)
+			continue
+		}
+		for _, v := range tc.matches {
+			if !c.Check(MustParse(v)) {
+				t.Errorf("Expected %s to satisfy %q", v, tc.constraint)
+			}
+		}
+		for _, v := range tc.rejects {
+			if c.Check(MustParse(v)) {
+				t.Errorf("Expected %s not to satisfy %q", v, tc.constraint)
+			}
+		}
+	}
+}
+
+func TestParseConstraintsInvalid(t *testing.T) {
+	inputs := []string{">=", "=>1.0", "1.2.3.4", "1.x.3", "!=1.2", "~>1.0", "^1.0-beta", "abc",
+		"^2 ||", "|| ^2", "^1 || || ^2", "^2 ||  , "}
+
+	for _, input := range inputs {
+		if _, err := ParseConstraints(input); !errors.Is(err, ErrInvalidConstraint) {
+			t.Errorf("ParseConstraints(%q): expected ErrInvalidConstraint, got %v", input, err)
+		}
+	}
+}
@@ -0,0 +1,206 @@
+package version
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidVersion 版本格式无效错误
+var ErrInvalidVersion = errors.New("invalid version format")
+
+// Version 语义化版本（SemVer 2.0）
+type Version struct {
+	Major uint64
+	Minor uint64
+	Patch uint64
+	// 预发布标识（如"beta.1"），为空表示正式版本
+	Prerelease string
+	// 构建元数据（如"20240101.abcdef"），不参与比较
+	Build string
+}
+
+// Parse 解析语义化版本，格式为MAJOR.MINOR.PATCH[-PRERELEASE][+BUILD]，允许带"v"前缀
+func Parse(s string) (Version, error) {
+	var v Version
+	str := strings.TrimPrefix(strings.TrimSpace(s), "v")
+
+	if i := strings.IndexByte(str, '+'); i >= 0 {
+		v.Build = str[i+1:]
+		str = str[:i]
+		if !validIdentifiers(v.Build, false) {
+			return Version{}, fmt.Errorf("%w: invalid build metadata in %q", ErrInvalidVersion, s)
+		}
+	}
+	if i := strings.IndexByte(str, '-'); i >= 0 {
+		v.Prerelease = str[i+1:]
+		str = str[:i]
+		if !validIdentifiers(v.Prerelease, true) {
+			return Version{}, fmt.Errorf("%w: invalid prerelease in %q", ErrInvalidVersion, s)
+		}
+	}
+
+	parts := strings.Split(str, ".")
+	if len(parts) != 3 {
+		return Version{}, fmt.Errorf("%w: %q", ErrInvalidVersion, s)
+	}
+	numbers := make([]uint64, 3)
+	for i, part := range parts {
+		n, err := parseNumber(part)
+		if err != nil {
+			return Version{}, fmt.Errorf("%w: %q", ErrInvalidVersion, s)
+		}
+		numbers[i] = n
+	}
+	v.Major, v.Minor, v.Patch = numbers[0], numbers[1], numbers[2]
+
+	return v, nil
+}
+
+// MustParse 解析语义化版本，格式无效时panic
+func MustParse(s string) Version {
+	v, err := Parse(s)
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+// Compare 比较两个版本字符串，a<b返回-1，a==b返回0，a>b返回1
+func Compare(a, b string) (int, error) {
+	va, err := Parse(a)
+	if err != nil {
+		return 0, err
+	}
+	vb, err := Parse(b)
+	if err != nil {
+		return 0, err
+	}
+	return va.Compare(vb), nil
+}
+
+// String 返回版本字符串（不带"v"前缀）
+func (v Version) String() string {
+	s := fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+	if v.Prerelease != "" {
+		s += "-" + v.Prerelease
+	}
+	if v.Build != "" {
+		s += "+" + v.Build
+	}
+	return s
+}
+
+// Compare 按SemVer 2.0规则比较版本，v<o返回-1，v==o返回0，v>o返回1
+// 预发布版本低于对应的正式版本，构建元数据不参与比较
+func (v Version) Compare(o Version) int {
+	if c := compareNumber(v.Major, o.Major); c != 0 {
+		return c
+	}
+	if c := compareNumber(v.Minor, o.Minor); c != 0 {
+		return c
+	}
+	if c := compareNumber(v.Patch, o.Patch); c != 0 {
+		return c
+	}
+	return comparePrerelease(v.Prerelease, o.Prerelease)
+}
+
+// LessThan 检查v是否低于o
+func (v Version) LessThan(o Version) bool {
+	return v.Compare(o) < 0
+}
+
+// Equal 检查v与o是否相同（忽略构建元数据）
+func (v Version) Equal(o Version) bool {
+	return v.Compare(o) == 0
+}
+
+// IsPrerelease 检查是否为预发布版本
+func (v Version) IsPrerelease() bool {
+	return v.Prerelease != ""
+}
+
+// comparePrerelease 按点分隔的标识逐个比较预发布部分：数字标识按数值比较且低于字母标识，
+// 前缀相同时标识较少的较低；没有预发布部分的版本最高
+func comparePrerelease(a, b string) int {
+	if a == b {
+		return 0
+	}
+	if a == "" {
+		return 1
+	}
+	if b == "" {
+		return -1
+	}
+
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		an, aErr := strconv.ParseUint(as[i], 10, 64)
+		bn, bErr := strconv.ParseUint(bs[i], 10, 64)
+		switch {
+		case aErr == nil && bErr == nil:
+			if c := compareNumber(an, bn); c != 0 {
+				return c
+			}
+		case aErr == nil:
+			return -1
+		case bErr == nil:
+			return 1
+		default:
+			if c := strings.Compare(as[i], bs[i]); c != 0 {
+				return c
+			}
+		}
+	}
+	return compareNumber(uint64(len(as)), uint64(len(bs)))
+}
+
+// compareNumber 比较两个数字
+func compareNumber(a, b uint64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// parseNumber 解析版本号中的数字部分，不允许前导零
+func parseNumber(s string) (uint64, error) {
+	if s == "" || (len(s) > 1 && s[0] == '0') {
+		return 0, ErrInvalidVersion
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return 0, ErrInvalidVersion
+		}
+	}
+	return strconv.ParseUint(s, 10, 64)
+}
+
+// validIdentifiers 检查点分隔的预发布或构建元数据标识，只允许字母、数字和"-"
+// 预发布中的纯数字标识不允许前导零
+func validIdentifiers(s string, prerelease bool) bool {
+	for _, id := range strings.Split(s, ".") {
+		if id == "" {
+			return false
+		}
+		numeric := true
+		for _, r := range id {
+			switch {
+			case r >= '0' && r <= '9':
+			case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r == '-':
+				numeric = false
+			default:
+				return false
+			}
+		}
+		if prerelease && numeric && len(id) > 1 && id[0] == '0' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,92 @@
+package version
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected Version
+	}{
+		{input: "1.2.3", expected: Version{Major: 1, Minor: 2, Patch: 3}},
+		{input: "v1.2.3", expected: Version{Major: 1, Minor: 2, Patch: 3}},
+		{input: "0.0.0", expected: Version{}},
+		{input: "1.0.0-beta.1", expected: Version{Major: 1, Prerelease: "beta.1"}},
+		{input: "1.0.0+20240101.sha-abc", expected: Version{Major: 1, Build: "20240101.sha-abc"}},
+		{input: "1.0.0-rc.1+build.5", expected: Version{Major: 1, Prerelease: "rc.1", Build: "build.5"}},
+	}
+
+	for _, tc := range testCases {
+		v, err := Parse(tc.input)
+		if err != nil {
+			t.Errorf("Parse(%q): expected no error, got %v", tc.input, err)
+			continue
+		}
+		if v != tc.expected {
+			t.Errorf("Parse(%q): expected %+v, got %+v", tc.input, tc.expected, v)
+		}
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	inputs := []string{"", "1", "1.2", "1.2.3.4", "01.2.3", "1.02.3", "1.2.x", "a.b.c", "1.2.3-", "1.2.3-01", "1.2.3+", "1.2.3-beta..1", "1.2.3-beta_1"}
+
+	for _, input := range inputs {
+		if _, err := Parse(input); !errors.Is(err, ErrInvalidVersion) {
+			t.Errorf("Parse(%q): expected ErrInvalidVersion, got %v", input, err)
+		}
+	}
+}
+
+func TestCompare(t *testing.T) {
+	// SemVer 2.0规范中的优先级示例，按从低到高排列
+	ordered := []string{
+		"1.0.0-alpha",
+		"1.0.0-alpha.1",
+		"1.0.0-alpha.beta",
+		"1.0.0-beta",
+		"1.0.0-beta.2",
+		"1.0.0-beta.11",
+		"1.0.0-rc.1",
+		"1.0.0",
+		"1.0.1",
+		"1.1.0",
+		"2.0.0",
+		"10.0.0",
+	}
+
+	for i := range ordered {
+		for j := range ordered {
+			expected := 0
+			if i < j {
+				expected = -1
+			} else if i > j {
+				expected = 1
+			}
+			if got := MustParse(ordered[i]).Compare(MustParse(ordered[j])); got != expected {
+				t.Errorf("Compare(%s, %s): expected %d, got %d", ordered[i], ordered[j], expected, got)
+			}
+		}
+	}
+
+	// 构建元数据不参与比较
+	if c, err := Compare("1.0.0+build.1", "v1.0.0+build.2"); err != nil || c != 0 {
+		t.Errorf("Expected build metadata to be ignored, got %d (%v)", c, err)
+	}
+	if _, err := Compare("1.0.0", "latest"); !errors.Is(err, ErrInvalidVersion) {
+		t.Errorf("Expected ErrInvalidVersion, got %v", err)
+	}
+}
+
+func TestString(t *testing.T) {
+	for _, input := range []string{"1.2.3", "1.0.0-beta.1", "1.0.0-rc.1+build.5"} {
+		if got := MustParse(input).String(); got != input {
+			t.Errorf("Expected %q, got %q", input, got)
+		}
+	}
+	if got := MustParse("v2.0.0").String(); got != "2.0.0" {
+		t.Errorf("Expected v prefix to be dropped, got %q", got)
+	}
+}
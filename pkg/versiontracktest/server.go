@@ -0,0 +1,487 @@
+// Package versiontracktest 提供进程内的VersionTrack测试服务器，用于在测试中验证完整的检查、下载、更新和上报流程
+//
+// 测试服务器实现了SDK使用的检查更新、文件下载和结果上报接口：
+//
+//	server := versiontracktest.NewServer()
+//	defer server.Close()
+//
+//	server.AddVersion(versiontracktest.Release{
+//		Version: "1.1.0",
+//		Files:   map[string]string{"app": "new binary"},
+//	})
+//	server.InjectFault(versiontracktest.EndpointDownload, versiontracktest.Fault{TruncateAfter: 100, Times: 1})
+//
+//	updater, _ := client.NewClient(&client.Config{ServerURL: server.URL, ...})
+package versiontracktest
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/CooperJiang/versiontrack-go-sdk/pkg/client"
+	"github.com/CooperJiang/versiontrack-go-sdk/pkg/version"
+)
+
+const (
+	// checkPath 检查更新接口
+	checkPath = "/api/v1/public/versions/check"
+	// downloadPrefix 文件下载接口前缀，完整路径为downloadPrefix + "{id}/download"
+	downloadPrefix = "/api/v1/public/versions/files/"
+	// reportsPath 更新结果上报接口
+	reportsPath = "/api/v1/public/versions/reports"
+	// chunkSize 注入延迟或截断时每次写入的字节数
+	chunkSize = 1024
+)
+
+// Endpoint 可注入故障的接口
+type Endpoint string
+
+const (
+	EndpointCheck    Endpoint = "check"    // 检查更新
+	EndpointDownload Endpoint = "download" // 文件下载
+	EndpointReport   Endpoint = "report"   // 结果上报
+)
+
+// Fault 注入的故障，多个字段可以组合使用
+type Fault struct {
+	// 直接返回该HTTP状态码（如500、503），不返回正常内容
+	Status int
+	// 响应前等待的时长；用于下载时在每个数据块之前等待，模拟慢速传输
+	Delay time.Duration
+	// 下载时只发送前N个字节后断开连接（Content-Length仍为完整大小），模拟传输中断
+	TruncateAfter int64
+	// 下载时篡改文件内容，使哈希和签名校验失败
+	Corrupt bool
+	// 生效次数，0表示一直生效
+	Times int
+}
+
+// Release 要发布的版本
+type Release struct {
+	// 版本号
+	Version string
+	// 更新包内容（相对路径 -> 文件内容），自动打包为tar.gz
+	Files map[string]string
+	// 自定义更新包内容，设置后忽略Files
+	Package []byte
+	// 更新包文件名，默认为"<版本号>.tar.gz"
+	FileName string
+	// 更新包压缩类型，默认为tar.gz；Package为单个可执行文件时设为"raw"
+	CompressionType string
+	// 更新包适用的平台和架构，为空表示适用所有平台和架构
+	Platform string
+	Arch     string
+	// 版本状态，默认published
+	Status string
+	// 发布渠道
+	Channel string
+	// 灰度发布百分比
+	RolloutPercentage *int
+	// 是否强制更新
+	IsForced bool
+	// 版本权重
+	VersionWeight int64
+	// 更新说明
+	Changelog string
+	// 签名私钥，设置后使用Ed25519对更新包签名
+	SigningKey ed25519.PrivateKey
+	// 附加的更新文件（如AddArtifact注册的差分补丁）
+	UpdateFiles []client.UpdateFile
+}
+
+// artifact 可下载的文件
+type artifact struct {
+	name string
+	data []byte
+}
+
+// fault 剩余生效次数的故障
+type fault struct {
+	Fault
+	remaining int
+}
+
+// Server 进程内的VersionTrack测试服务器
+type Server struct {
+	// 服务器地址，用作client.Config.ServerURL
+	URL string
+
+	server *httptest.Server
+
+	mu        sync.Mutex
+	versions  []client.VersionInfo
+	artifacts map[string]*artifact
+	faults    map[Endpoint][]*fault
+	checks    []url.Values
+	downloads map[string]int
+	reports   []client.InstallReport
+	nextID    int
+}
+
+// NewServer 启动测试服务器，使用完毕后需调用Close
+func NewServer() *Server {
+	s := &Server{
+		artifacts: make(map[string]*artifact),
+		faults:    make(map[Endpoint][]*fault),
+		downloads: make(map[string]int),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(checkPath, s.handleCheck)
+	mux.HandleFunc(downloadPrefix, s.handleDownload)
+	mux.HandleFunc(reportsPath, s.handleReport)
+	s.server = httptest.NewServer(mux)
+	s.URL = s.server.URL
+
+	return s
+}
+
+// Close 关闭测试服务器
+func (s *Server) Close() {
+	s.server.Close()
+}
+
+// AddArtifact 注册可下载的文件，返回包含下载地址、大小和SHA-256哈希的更新文件信息
+// 返回值可补充FileType、FilePath等字段后通过Release.UpdateFiles附加到版本上
+func (s *Server) AddArtifact(name string, data []byte) client.UpdateFile {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.nextID++
+	id := fmt.Sprintf("file-%d", s.nextID)
+	s.artifacts[id] = &artifact{name: name, data: append([]byte(nil), data...)}
+
+	return client.UpdateFile{
+		ID:          id,
+		FileName:    name,
+		FileSize:    int64(len(data)),
+		FileHash:    SHA256(data),
+		DownloadURL: s.URL + downloadPrefix + id + "/download",
+	}
+}
+
+// AddVersion 发布版本，更新包由Files打包或直接使用Package，返回服务器下发的版本信息
+func (s *Server) AddVersion(r Release) (client.VersionInfo, error) {
+	if r.Version == "" {
+		return client.VersionInfo{}, fmt.Errorf("version is required")
+	}
+
+	data := r.Package
+	compression := r.CompressionType
+	if data == nil {
+		packed, err := TarGz(r.Files)
+		if err != nil {
+			return client.VersionInfo{}, fmt.Errorf("failed to build package: %w", err)
+		}
+		data = packed
+	}
+	if compression == "" {
+		compression = "tar.gz"
+	}
+	name := r.FileName
+	if name == "" {
+		name = r.Version + ".tar.gz"
+		if compression == "raw" {
+			name = r.Version
+		}
+	}
+
+	file := s.AddArtifact(name, data)
+	file.Platform = r.Platform
+	file.Arch = r.Arch
+	file.CompressionType = compression
+	file.IsCompressed = compression != "raw"
+	if r.SigningKey != nil {
+		digest := sha256.Sum256(data)
+		file.Signature = base64.StdEncoding.EncodeToString(ed25519.Sign(r.SigningKey, digest[:]))
+		file.SignatureAlgorithm = "ed25519"
+	}
+
+	status := r.Status
+	if status == "" {
+		status = "published"
+	}
+	info := client.VersionInfo{
+		Version:            r.Version,
+		VersionWeight:      r.VersionWeight,
+		Changelog:          r.Changelog,
+		ReleaseDate:        time.Now().Format(time.RFC3339),
+		DownloadURL:        file.DownloadURL,
+		FileSize:           file.FileSize,
+		FileHash:           file.FileHash,
+		Status:             status,
+		IsDownloadable:     true,
+		IsForced:           r.IsForced,
+		Signature:          file.Signature,
+		SignatureAlgorithm: file.SignatureAlgorithm,
+		Channel:            r.Channel,
+		RolloutPercentage:  r.RolloutPercentage,
+		UpdateFiles:        append([]client.UpdateFile{file}, r.UpdateFiles...),
+	}
+
+	s.mu.Lock()
+	s.versions = append(s.versions, info)
+	s.mu.Unlock()
+
+	return info, nil
+}
+
+// MustAddVersion 发布版本，失败时panic
+func (s *Server) MustAddVersion(r Release) client.VersionInfo {
+	info, err := s.AddVersion(r)
+	if err != nil {
+		panic(err)
+	}
+	return info
+}
+
+// InjectFault 为接口注入故障，同一接口的多个故障按注入顺序依次生效
+func (s *Server) InjectFault(endpoint Endpoint, f Fault) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.faults[endpoint] = append(s.faults[endpoint], &fault{Fault: f, remaining: f.Times})
+}
+
+// ClearFaults 清除所有注入的故障
+func (s *Server) ClearFaults() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.faults = make(map[Endpoint][]*fault)
+}
+
+// CheckRequests 返回收到的检查更新请求的查询参数（platform、arch、currentVersion、channel、bucket等）
+func (s *Server) CheckRequests() []url.Values {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]url.Values(nil), s.checks...)
+}
+
+// Downloads 返回文件被请求下载的次数（含失败的请求）
+func (s *Server) Downloads(file client.UpdateFile) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.downloads[file.ID]
+}
+
+// Reports 返回收到的更新结果
+func (s *Server) Reports() []client.InstallReport {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]client.InstallReport(nil), s.reports...)
+}
+
+// takeFault 取出接口当前生效的故障，没有故障时返回nil
+func (s *Server) takeFault(endpoint Endpoint) *Fault {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	faults := s.faults[endpoint]
+	if len(faults) == 0 {
+		return nil
+	}
+
+	f := faults[0]
+	if f.Times > 0 {
+		f.remaining--
+		if f.remaining <= 0 {
+			s.faults[endpoint] = faults[1:]
+		}
+	}
+	return &f.Fault
+}
+
+// applyFault 执行状态码和延迟故障，已写入响应时返回true
+func applyFault(w http.ResponseWriter, r *http.Request, f *Fault, delay bool) bool {
+	if f == nil {
+		return false
+	}
+	if delay && f.Delay > 0 && !sleep(r, f.Delay) {
+		return true
+	}
+	if f.Status != 0 {
+		http.Error(w, http.StatusText(f.Status), f.Status)
+		return true
+	}
+	return false
+}
+
+// sleep 等待指定时长，请求被取消时返回false
+func sleep(r *http.Request, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-r.Context().Done():
+		return false
+	}
+}
+
+// handleCheck 返回比currentVersion更新且匹配平台和架构的版本，按版本从高到低排序
+// 渠道和灰度由客户端在本地过滤，测试服务器不做处理
+func (s *Server) handleCheck(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	s.mu.Lock()
+	s.checks = append(s.checks, query)
+	s.mu.Unlock()
+
+	if applyFault(w, r, s.takeFault(EndpointCheck), true) {
+		return
+	}
+
+	current := query.Get("currentVersion")
+	platform, arch := query.Get("platform"), query.Get("arch")
+
+	s.mu.Lock()
+	var available []client.VersionInfo
+	for _, v := range s.versions {
+		if newerThan(v.Version, current) && matchesPlatform(v.UpdateFiles[0], platform, arch) {
+			available = append(available, v)
+		}
+	}
+	s.mu.Unlock()
+
+	sort.SliceStable(available, func(i, j int) bool {
+		return newerThan(available[i].Version, available[j].Version)
+	})
+
+	updates := client.UpdatesInfo{
+		HasUpdate:         len(available) > 0,
+		CurrentVersion:    current,
+		AvailableVersions: available,
+	}
+	if len(available) > 0 {
+		updates.LatestVersion = available[0].Version
+		updates.UpdateFiles = available[0].UpdateFiles
+	}
+	for _, v := range available {
+		if v.IsForced {
+			updates.UpdateStrategy.HasForced = true
+		}
+	}
+
+	writeJSON(w, map[string]interface{}{"code": 200, "message": "ok", "data": updates})
+}
+
+// handleDownload 下载已注册的文件，支持Range续传
+func (s *Server) handleDownload(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, downloadPrefix), "/download")
+
+	s.mu.Lock()
+	art := s.artifacts[id]
+	s.downloads[id]++
+	s.mu.Unlock()
+
+	if art == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	f := s.takeFault(EndpointDownload)
+	if applyFault(w, r, f, false) {
+		return
+	}
+
+	data := art.data
+	if f != nil && f.Corrupt && len(data) > 0 {
+		data = append([]byte(nil), data...)
+		data[len(data)/2] ^= 0xff
+	}
+
+	w.Header().Set("ETag", `"`+SHA256(art.data)+`"`)
+	if f == nil || (f.Delay == 0 && f.TruncateAfter == 0) {
+		http.ServeContent(w, r, art.name, time.Unix(0, 0), bytes.NewReader(data))
+		return
+	}
+
+	// 慢速或截断的传输始终从头发送完整内容
+	w.Header().Set("Content-Length", fmt.Sprint(len(data)))
+	w.WriteHeader(http.StatusOK)
+	var written int64
+	for written < int64(len(data)) {
+		if f.Delay > 0 && !sleep(r, f.Delay) {
+			return
+		}
+
+		end := written + chunkSize
+		if end > int64(len(data)) {
+			end = int64(len(data))
+		}
+		if f.TruncateAfter > 0 && end > f.TruncateAfter {
+			end = f.TruncateAfter
+		}
+		if _, err := w.Write(data[written:end]); err != nil {
+			return
+		}
+		written = end
+		if flusher, ok := w.(http.Flusher); ok {
+			flusher.Flush()
+		}
+
+		if f.TruncateAfter > 0 && written >= f.TruncateAfter {
+			panic(http.ErrAbortHandler)
+		}
+	}
+}
+
+// handleReport 记录上报的更新结果
+func (s *Server) handleReport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	if applyFault(w, r, s.takeFault(EndpointReport), true) {
+		return
+	}
+
+	var report client.InstallReport
+	if err := json.NewDecoder(r.Body).Decode(&report); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	s.mu.Lock()
+	s.reports = append(s.reports, report)
+	s.mu.Unlock()
+
+	writeJSON(w, map[string]interface{}{"code": 200, "message": "ok"})
+}
+
+// newerThan 检查版本a是否高于b，b为空时视为更高，无法按语义化版本解析时按字符串比较
+func newerThan(a, b string) bool {
+	if b == "" {
+		return true
+	}
+	if c, err := version.Compare(a, b); err == nil {
+		return c > 0
+	}
+	return a > b
+}
+
+// matchesPlatform 检查更新文件是否适用于请求的平台和架构
+func matchesPlatform(file client.UpdateFile, platform, arch string) bool {
+	return (file.Platform == "" || file.Platform == platform) && (file.Arch == "" || file.Arch == arch)
+}
+
+// writeJSON 写入JSON响应
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,60 @@
+package versiontracktest
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"testing"
+)
+
+func TestTarGzIsDeterministic(t *testing.T) {
+	files := map[string]string{"app": "binary", "lib/a.so": "a", "lib/b.so": "b"}
+
+	first, err := TarGz(files)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := TarGz(files)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(first, second) || SHA256(first) != SHA256(second) {
+		t.Error("Expected identical packages for identical file maps")
+	}
+}
+
+func TestInjectFaultExpires(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+
+	file := server.AddArtifact("app", []byte("content"))
+	server.InjectFault(EndpointDownload, Fault{Status: http.StatusInternalServerError, Times: 2})
+
+	for i, expected := range []int{500, 500, 200} {
+		resp, err := http.Get(file.DownloadURL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+
+		if resp.StatusCode != expected {
+			t.Errorf("Request %d: expected status %d, got %d", i, expected, resp.StatusCode)
+		}
+		if expected == 200 && string(body) != "content" {
+			t.Errorf("Expected artifact content, got %q", body)
+		}
+	}
+	if n := server.Downloads(file); n != 3 {
+		t.Errorf("Expected 3 downloads, got %d", n)
+	}
+}
+
+func TestAddVersionRequiresVersion(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+
+	if _, err := server.AddVersion(Release{Files: map[string]string{"app": "binary"}}); err == nil {
+		t.Error("Expected error for release without version")
+	}
+}